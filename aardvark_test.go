@@ -71,9 +71,10 @@ func setupJVM(t *testing.T) (env *Environment) {
 	}
 	t.Logf("Testing -- using classpath [../../../java/,%s", DefaultJREPath)
 	var err error
-	_jvm, env, err = NewJVM(0, JvmConfig{[]string{"../../../java/", DefaultJREPath}})
+	_jvm, env, err = NewJVM(0, JvmConfig{ClassPath: []string{"../../../java/", DefaultJREPath}})
 	fatalIf(t, err != nil, "Error initializing JVM: %v", err)
 	fatalIf(t, _jvm == nil, "Got a nil context!")
+	defineFixtures(t, env)
 	// expected exceptions are pre-muted/unmuted, but if you're testing something
 	// that causes them to throw, and want readable tests, this is the line
 	// to uncomment.
@@ -1,12 +1,12 @@
 package gojvm
 //#cgo CFLAGS:-I../include/
-//#cgo LDFLAGS:-ljvm	-L/usr/lib/jvm/default-java/jre/lib/amd64/server
 //#include "helpers.h"
 import "C"
 import (
 	"errors"
 	"fmt"
-
+	"github.com/timob/gojvm/types"
+	"reflect"
 	"strconv"
 	"unsafe"
 )
@@ -59,6 +59,20 @@ func newArgList(ctx *Environment, params ...interface{}) (alp argList, objStack
 		switch v := param.(type) {
 		case int:
 			alp = append(alp, C.intValue(C.jint(v)))
+		case int32:
+			alp = append(alp, C.intValue(C.jint(v)))
+		case int16:
+			alp = append(alp, C.shortValue(C.jshort(v)))
+		case int8:
+			alp = append(alp, C.byteValue(C.jbyte(v)))
+		case uint8:
+			alp = append(alp, C.byteValue(C.jbyte(v)))
+		case uint16:
+			alp = append(alp, C.charValue(C.jchar(v)))
+		case float32:
+			alp = append(alp, C.floatValue(C.jfloat(v)))
+		case float64:
+			alp = append(alp, C.doubleValue(C.jdouble(v)))
 		case int64:
 			alp = append(alp, C.longValue(C.jlong(v)))
 		case C.jstring:
@@ -69,8 +83,16 @@ func newArgList(ctx *Environment, params ...interface{}) (alp argList, objStack
 			alp = append(alp, C.intValue(v))
 		case C.jobject:
 			alp = append(alp, C.objValue(v))
+		case nil:
+			alp = append(alp, C.objValue(nil))
+		case *NullObject:
+			alp = append(alp, C.objValue(nil))
 		case *Object:
-			alp = append(alp, C.objValue(v.object))
+			if v == nil {
+				alp = append(alp, C.objValue(nil))
+			} else {
+				alp = append(alp, C.objValue(v.object))
+			}
 		case *CastObject:
 			alp = append(alp, C.objValue(v.Object.object))
 		case *Class:
@@ -142,8 +164,36 @@ func newArgList(ctx *Environment, params ...interface{}) (alp argList, objStack
 			obj, err = ctx.newByteObject(v)
 			if err == nil {
 				alp = append(alp, C.objValue(obj.object))
+				objStack = append(objStack, obj)
+			}
+		case []int8:
+			var obj *Object
+			obj, err = ctx.newByteObject(*(*[]byte)(unsafe.Pointer(&v)))
+			if err == nil {
+				alp = append(alp, C.objValue(obj.object))
+				objStack = append(objStack, obj)
+			}
+		case []int:
+			var obj *Object
+			obj, err = ctx.newIntObject(v)
+			if err == nil {
+				alp = append(alp, C.objValue(obj.object))
+				objStack = append(objStack, obj)
+			}
+		case []int64:
+			var obj *Object
+			obj, err = ctx.newLongObject(v)
+			if err == nil {
+				alp = append(alp, C.objValue(obj.object))
+				objStack = append(objStack, obj)
+			}
+		case []uint16:
+			var obj *Object
+			obj, err = ctx.newCharObject(v)
+			if err == nil {
+				alp = append(alp, C.objValue(obj.object))
+				objStack = append(objStack, obj)
 			}
-			objStack = append(objStack, obj)
 		case bool:
 			val := C.jboolean(C.JNI_FALSE)
 			if v {
@@ -163,9 +213,116 @@ func newArgList(ctx *Environment, params ...interface{}) (alp argList, objStack
 	return
 }
 
+// Like newArgList, but first boxes (or unboxes) any parameter whose Go value
+// doesn't match the kind declared by the resolved method (see coerceParams).
+// Boxed objects are returned in the objStack along with newArgList's own.
+func newMethodArgList(ctx *Environment, meth *Method, params ...interface{}) (alp argList, objStack []*Object, err error) {
+	params, boxed, err := ctx.coerceParams(meth.sig.Params, params)
+	if err == nil {
+		alp, objStack, err = newArgList(ctx, params...)
+	}
+	if err != nil {
+		blowStack(ctx, boxed)
+		return
+	}
+	objStack = append(objStack, boxed...)
+	return
+}
+
 // Essentially, the java generic.  Type information is NOT carried
 // with the value, however is required for proper use.  (don't use
 // unless you know the distinction between jobject, jclass and jvalue).
 type Value struct {
 	val C.jvalue
 }
+
+/*
+	Converts the Go number (or bool) v to a jvalue of the primitive kind k,
+	as a declared signature requires;  so an int may be passed for a long or
+	double.  Floating point values are not truncated into integer kinds
+	(ErrWrongKind).
+*/
+func primitiveValue(k types.Kind, v interface{}) (jv C.jvalue, err error) {
+	rv := reflect.ValueOf(v)
+	var i int64
+	var f float64
+	isFloat := false
+	switch rv.Kind() {
+	case reflect.Bool:
+		if k != types.BoolKind {
+			return jv, ErrWrongKind
+		}
+		val := C.jboolean(C.JNI_FALSE)
+		if rv.Bool() {
+			val = C.JNI_TRUE
+		}
+		return C.boolValue(val), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i = rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i = int64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		f, isFloat = rv.Float(), true
+	default:
+		return jv, ErrWrongKind
+	}
+	if isFloat && k != types.FloatKind && k != types.DoubleKind {
+		return jv, ErrWrongKind
+	}
+	if !isFloat {
+		f = float64(i)
+	}
+	switch k {
+	case types.ByteKind:
+		jv = C.byteValue(C.jbyte(i))
+	case types.CharKind:
+		jv = C.charValue(C.jchar(i))
+	case types.ShortKind:
+		jv = C.shortValue(C.jshort(i))
+	case types.IntKind:
+		jv = C.intValue(C.jint(i))
+	case types.LongKind:
+		jv = C.longValue(C.jlong(i))
+	case types.FloatKind:
+		jv = C.floatValue(C.jfloat(f))
+	case types.DoubleKind:
+		jv = C.doubleValue(C.jdouble(f))
+	default:
+		err = ErrWrongKind
+	}
+	return
+}
+
+/*
+	Builds the argument list for a call through a known signature (see
+	MethodHandle);  primitives are converted to their declared kinds by
+	primitiveValue, and everything else is passed as by newMethodArgList.
+*/
+func newTypedArgList(ctx *Environment, ptypes []types.Typed, params ...interface{}) (alp argList, objStack []*Object, err error) {
+	if len(params) != len(ptypes) {
+		return nil, nil, ErrArgCount
+	}
+	params, objStack, err = ctx.coerceParams(ptypes, params)
+	if err != nil {
+		return
+	}
+	alp = make(argList, len(params))
+	for i, param := range params {
+		if k := ptypes[i].Kind(); k != types.ClassKind && k != types.ArrayKind {
+			alp[i], err = primitiveValue(k, param)
+		} else {
+			var one argList
+			var stack []*Object
+			one, stack, err = newArgList(ctx, param)
+			if err == nil {
+				alp[i] = one[0]
+				objStack = append(objStack, stack...)
+			}
+		}
+		if err != nil {
+			blowStack(ctx, objStack)
+			return nil, nil, err
+		}
+	}
+	return
+}
@@ -0,0 +1,105 @@
+package gojvm
+
+//#include "helpers.h"
+import "C"
+import (
+	"github.com/timob/gojvm/types"
+	"sync"
+)
+
+/*
+	A Java class as generated bindings (see cmd/gojvm-bindgen) use it:  its
+	method and field handles are resolved by name and descriptor on first
+	use, and kept (with a global ref of the class) for the life of the
+	process, so that calls through the bindings only pay for the call.
+*/
+type Binding struct {
+	Name    types.Name
+	lock    sync.Mutex
+	class   *Class
+	methods map[string]*MethodHandle
+	fields  map[string]*FieldHandle
+}
+
+func NewBinding(name string) *Binding {
+	return &Binding{
+		Name:    types.NewName(name),
+		methods: map[string]*MethodHandle{},
+		fields:  map[string]*FieldHandle{},
+	}
+}
+
+// the class (as a global ref of its own);  called with the lock held
+func (self *Binding) resolve(env *Environment) (class *Class, err error) {
+	if self.class != nil {
+		return self.class, nil
+	}
+	if class, err = env.GetClass(self.Name); err != nil {
+		return
+	}
+	self.class = newClass(C.jclass(C.envNewGlobalRef(env.env, C.jobject(class.class))))
+	return self.class, nil
+}
+
+// the bound class
+func (self *Binding) Class(env *Environment) (class *Class, err error) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	return self.resolve(env)
+}
+
+// the handle of the method (or constructor, "<init>") of the name and descriptor
+func (self *Binding) Method(env *Environment, name, desc string) (h *MethodHandle, err error) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if h = self.methods[name+desc]; h != nil {
+		return
+	}
+	sig, err := types.ParseMethodSignature(desc)
+	if err != nil {
+		return
+	}
+	class, err := self.resolve(env)
+	if err != nil {
+		return
+	}
+	if h, err = class.Method(env, name, sig); err == nil {
+		self.methods[name+desc] = h
+	}
+	return
+}
+
+// the handle of the field of the name and descriptor
+func (self *Binding) Field(env *Environment, name, desc string) (h *FieldHandle, err error) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if h = self.fields[name+":"+desc]; h != nil {
+		return
+	}
+	t, err := types.ParseTypeString(desc)
+	if err != nil {
+		return
+	}
+	class, err := self.resolve(env)
+	if err != nil {
+		return
+	}
+	if h, err = class.Field(env, name, t); err == nil {
+		self.fields[name+":"+desc] = h
+	}
+	return
+}
+
+/*
+	Converts the result of a String-returning call (a local ref, as from
+	InvokeObj or GetObj) to a Go string ("" for null), releasing the ref;
+	err, if any, is passed on.
+*/
+func (self *Environment) StringResult(obj *Object, err error) (s string, err2 error) {
+	if err != nil || obj == nil {
+		return "", err
+	}
+	defer self.DeleteLocalRef(obj)
+	s, _, err2 = self.ToString(obj)
+	return
+}
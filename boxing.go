@@ -0,0 +1,131 @@
+package gojvm
+
+import (
+	"github.com/timob/gojvm/types"
+)
+
+// true if v is a Go value newArgList passes as a JNI primitive
+func isPrimitive(v interface{}) bool {
+	switch v.(type) {
+	case bool, int8, uint8, uint16, int16, int, int32, int64, float32, float64:
+		return true
+	}
+	return false
+}
+
+/*
+	Returns a new java/lang wrapper (Boolean, Byte, Character, Short, Integer,
+	Long, Float or Double) holding the Go primitive v, as chosen by TypeOf
+	(so uint16 boxes to Character, and int to Integer).
+
+	The returned object is a local reference, obtained via the wrapper's valueOf.
+*/
+func (self *Environment) Box(v interface{}) (obj *Object, err error) {
+	if !isPrimitive(v) {
+		return nil, ErrNotBoxed
+	}
+	k, err := TypeOf(self, v)
+	if err != nil {
+		return
+	}
+	name, ok := types.BoxedName(k.Kind())
+	if !ok {
+		return nil, ErrNotBoxed
+	}
+	class, err := self.GetClass(name)
+	if err != nil {
+		return
+	}
+	return class.CallObj(self, true, "valueOf", types.Class{name}, v)
+}
+
+/*
+	Returns the Go value held by a java/lang primitive wrapper;  the Go type
+	is the one the Call* methods return for the primitive (Integer => int,
+	Long => int64, Short => int16, Byte => int8, Character => uint16,
+	Boolean => bool, Float => float32, Double => float64).
+
+	ErrNotBoxed is returned for any other class, and ErrNullUnbox for null.
+*/
+func (self *Environment) Unbox(obj *Object) (v interface{}, err error) {
+	if obj == nil {
+		return nil, ErrNullUnbox
+	}
+	name, err := obj.Name(self)
+	if err != nil {
+		return
+	}
+	kind, ok := types.UnboxedKind(name)
+	if !ok {
+		return nil, ErrNotBoxed
+	}
+	switch kind {
+	case types.BoolKind:
+		v, err = obj.CallBool(self, false, "booleanValue")
+	case types.ByteKind:
+		v, err = self.callByte(obj, false, "byteValue")
+	case types.CharKind:
+		v, err = self.callChar(obj, false, "charValue")
+	case types.ShortKind:
+		v, err = obj.CallShort(self, false, "shortValue")
+	case types.IntKind:
+		v, err = obj.CallInt(self, false, "intValue")
+	case types.LongKind:
+		v, err = obj.CallLong(self, false, "longValue")
+	case types.FloatKind:
+		v, err = obj.CallFloat(self, false, "floatValue")
+	case types.DoubleKind:
+		v, err = obj.CallDouble(self, false, "doubleValue")
+	}
+	return
+}
+
+/*
+	Adapts params to the declared parameter types of a resolved method:
+	Go primitives passed where an object is declared are boxed, and wrapper
+	objects passed where a primitive is declared are unboxed (and runes passed
+	where a char is declared are narrowed to one).  Parameters that
+	already match are passed through untouched.
+
+	Any boxed objects are returned (as local refs) for the caller to release.
+*/
+func (self *Environment) coerceParams(declared []types.Typed, params []interface{}) (out []interface{}, boxed []*Object, err error) {
+	out = params
+	for i, param := range params {
+		if i >= len(declared) {
+			break
+		}
+		var v interface{}
+		switch declared[i].Kind() {
+		case types.ClassKind:
+			if isPrimitive(param) {
+				var obj *Object
+				obj, err = self.Box(param)
+				if err == nil {
+					boxed = append(boxed, obj)
+					v = obj
+				}
+			}
+		case types.ArrayKind:
+		default:
+			if _, null := param.(*NullObject); param == nil || null {
+				err = ErrNullUnbox
+			} else if obj, ok := param.(*Object); ok {
+				v, err = self.Unbox(obj)
+			} else if _, r := param.(int32); r && declared[i].Kind() == types.CharKind {
+				v, err = primitiveValue(types.CharKind, param)
+			}
+		}
+		if err != nil {
+			blowStack(self, boxed)
+			return nil, nil, err
+		}
+		if v != nil {
+			if &out[0] == &params[0] {
+				out = append([]interface{}{}, params...)
+			}
+			out[i] = v
+		}
+	}
+	return
+}
@@ -1,6 +1,5 @@
 package gojvm
 
-//#cgo LDFLAGS:-ljvm	-L/usr/lib/jvm/default-java/jre/lib/amd64/server
 //#include "helpers.h"
 import "C"
 import (
@@ -17,6 +16,13 @@ func newClass(class C.jclass) *Class {
 	return &Class{class}
 }
 
+// the class as a plain java/lang/Class *Object, for calling the methods of
+// java.lang.Class itself (the Class.CallXXX methods dispatch against the
+// class represented).  No reference is added.
+func (self *Class) asObject() *Object {
+	return newObject(C.jobject(self.class))
+}
+
 /*
 	returns the (potentially cached) types.Name of the class.
 */
@@ -30,6 +36,18 @@ func (self *Class) GetName(env *Environment) (name types.Name, err error) {
 	return
 }
 
+/*
+	Returns the superclass of the class (as a local ref), or nil for
+	java/lang/Object, interfaces and primitive types.
+*/
+func (self *Class) Superclass(env *Environment) (super *Class) {
+	c := C.envGetSuperclass(env.env, self.class)
+	if c != nil {
+		super = newClass(c)
+	}
+	return
+}
+
 // Calls the named void-method on the class
 func (self *Class) CallVoid(env *Environment, static bool, mname string, params ...interface{}) (err error) {
 	return env.CallClassVoid(self, static, mname, params...)
@@ -39,6 +57,14 @@ func (self *Class) CallBoolean(env *Environment, static bool, mname string, para
 	return env.CallClassBool(self, static, mname, params...)
 }
 
+func (self *Class) CallByte(env *Environment, static bool, mname string, params ...interface{}) (i int8, err error) {
+	return env.CallClassByte(self, static, mname, params...)
+}
+
+func (self *Class) CallChar(env *Environment, static bool, mname string, params ...interface{}) (i uint16, err error) {
+	return env.CallClassChar(self, static, mname, params...)
+}
+
 func (self *Class) CallShort(env *Environment, static bool, mname string, params ...interface{}) (i int16, err error) {
 	return env.CallClassShort(self, static, mname, params...)
 }
@@ -59,6 +85,11 @@ func (self *Class) CallFloat(env *Environment, static bool, mname string, params
 	return env.CallClassFloat(self, static, mname, params...)
 }
 
+// Calls the named method, returning the result as a Go value (see Environment.Call)
+func (self *Class) Call(env *Environment, static bool, mname string, rval types.Typed, params ...interface{}) (v interface{}, err error) {
+	return env.Call(self, static, mname, rval, params...)
+}
+
 func (self *Class) CallObj(env *Environment, static bool, mname string, rval types.Typed, params ...interface{}) (o *Object, err error) {
 	return env.CallClassObj(self, static, mname, rval, params...)
 }
@@ -75,6 +106,14 @@ func (self *Class) CallIntArray(env *Environment, static bool, mname string, par
 	return env.CallClassIntArray(self, static, mname, params...)
 }
 
+func (self *Class) CallByteArray(env *Environment, static bool, mname string, params ...interface{}) (i []int8, err error) {
+	return env.CallClassByteArray(self, static, mname, params...)
+}
+
+func (self *Class) CallCharArray(env *Environment, static bool, mname string, params ...interface{}) (i []uint16, err error) {
+	return env.CallClassCharArray(self, static, mname, params...)
+}
+
 //fields
 
 func (self *Class) GetObjField(env *Environment, static bool, name string, rval types.Typed) (*Object, error) {
@@ -85,6 +124,14 @@ func (self *Class) GetBooleanField(env *Environment, static bool, name string) (
 	return env.GetClassBooleanField(self, static, name)
 }
 
+func (self *Class) GetByteField(env *Environment, static bool, name string) (int8, error) {
+	return env.GetClassByteField(self, static, name)
+}
+
+func (self *Class) GetCharField(env *Environment, static bool, name string) (uint16, error) {
+	return env.GetClassCharField(self, static, name)
+}
+
 func (self *Class) GetShortField(env *Environment, static bool, name string) (int16, error) {
 	return env.GetClassShortField(self, static, name)
 }
@@ -105,4 +152,44 @@ func (self *Class) GetDoubleField(env *Environment, static bool, name string) (f
 	return env.GetClassDoubleField(self, static, name)
 }
 
+func (self *Class) SetObjField(env *Environment, static bool, name string, rval types.Typed, val *Object) (err error) {
+	return env.setObjField(self, static, name, rval, val)
+}
+
+func (self *Class) SetBooleanField(env *Environment, static bool, name string, val bool) (err error) {
+	return env.setBoolField(self, static, name, val)
+}
+
+func (self *Class) SetByteField(env *Environment, static bool, name string, val int8) (err error) {
+	return env.setByteField(self, static, name, val)
+}
+
+func (self *Class) SetCharField(env *Environment, static bool, name string, val uint16) (err error) {
+	return env.setCharField(self, static, name, val)
+}
+
+func (self *Class) SetShortField(env *Environment, static bool, name string, val int16) (err error) {
+	return env.setShortField(self, static, name, val)
+}
+
+func (self *Class) SetIntField(env *Environment, static bool, name string, val int) (err error) {
+	return env.setIntField(self, static, name, val)
+}
+
+func (self *Class) SetLongField(env *Environment, static bool, name string, val int64) (err error) {
+	return env.setLongField(self, static, name, val)
+}
 
+func (self *Class) SetFloatField(env *Environment, static bool, name string, val float32) (err error) {
+	return env.setFloatField(self, static, name, val)
+}
+
+func (self *Class) SetDoubleField(env *Environment, static bool, name string, val float64) (err error) {
+	return env.setDoubleField(self, static, name, val)
+}
+
+// Sets an array field from a Go slice ([]int, []int64, []int8, []byte, []uint16, []string)
+// or *ObjectArray;  the field type is inferred from val.
+func (self *Class) SetArrayField(env *Environment, static bool, name string, val interface{}) (err error) {
+	return env.setArrayField(self, static, name, val)
+}
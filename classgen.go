@@ -0,0 +1,67 @@
+package gojvm
+
+import (
+	"github.com/timob/gojvm/types"
+)
+
+// a native method of a generated class, and the Go func implementing it
+type goMethod struct {
+	name string
+	sig  types.MethodSignature
+	f    interface{}
+}
+
+/*
+	Defines the generated class name (in the system class loader) and binds
+	its natives, unless this JVM already has;  the class then stays defined
+	for the life of the JVM.
+*/
+func (self *JVM) defineGoClass(env *Environment, name types.Name, bytecode []byte, natives ...goMethod) (err error) {
+	self.defineLock.Lock()
+	defer self.defineLock.Unlock()
+	if self.defined[name.AsPath()] {
+		return
+	}
+	class, err := env.DefineClass(name, nil, bytecode)
+	if err != nil {
+		return
+	}
+	env.DeleteGlobalClassRef(class)
+	for _, m := range natives {
+		if err = env.RegisterNative(name.AsPath(), m.name, m.sig, m.f); err != nil {
+			return
+		}
+	}
+	if self.defined == nil {
+		self.defined = map[string]bool{}
+	}
+	self.defined[name.AsPath()] = true
+	return
+}
+
+// keeps v reachable for Java objects to refer to (by the returned id)
+func (self *JVM) keep(v interface{}) (id int64) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if self.kept == nil {
+		self.kept = map[int64]interface{}{}
+	}
+	id = self.keptId
+	self.kept[id] = v
+	self.keptId++
+	return
+}
+
+// the value kept as id (or nil)
+func (self *JVM) keptValue(id int64) interface{} {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	return self.kept[id]
+}
+
+// forgets the value kept as id
+func (self *JVM) unkeep(id int64) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	delete(self.kept, id)
+}
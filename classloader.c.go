@@ -0,0 +1,237 @@
+package gojvm
+
+//#include "helpers.h"
+import "C"
+import (
+	"github.com/timob/gojvm/types"
+)
+
+var classLoaderClass = types.Name{"java", "lang", "ClassLoader"}
+var urlClassLoaderClass = types.Name{"java", "net", "URLClassLoader"}
+var urlClass = types.Name{"java", "net", "URL"}
+var uriClass = types.Name{"java", "net", "URI"}
+
+/*
+	Adds a jar file or class directory to the classes GetClass can find, in
+	every Environment of the JVM.  Entries are searched (in the order added)
+	after the JvmConfig.ClassPath, by a URLClassLoader chained onto the
+	system class loader.
+
+	It may be called from any goroutine;  one whose thread isn't attached to
+	the JVM is attached for the call, and detached again after.
+*/
+func (self *JVM) AddClassPath(path string) (err error) {
+	env, attached, err := self.currentEnv()
+	if err != nil {
+		return
+	}
+	defer self.doneEnv(attached)
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	parent := self.classPath
+	if parent == nil {
+		if parent, err = env.systemClassLoader(); err != nil {
+			return
+		}
+		defer env.DeleteLocalRef(parent)
+	}
+	loader, err := env.newURLClassLoader(parent, []string{path})
+	if err != nil {
+		return
+	}
+	// the new loader holds its parent for us
+	if self.classPath != nil {
+		env.DeleteGlobalRef(self.classPath)
+	}
+	self.classPath = loader
+	return
+}
+
+// the loaders GetClass tries when FindClass misses:  the home loader, then that of AddClassPath
+func (self *JVM) fallbackLoaders() (loaders []*Object) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	for _, loader := range []*Object{self.home, self.classPath} {
+		if loader != nil {
+			loaders = append(loaders, loader)
+		}
+	}
+	return
+}
+
+/*
+	Makes loader the JVM's "home" class loader:  GetClass resolves names
+	through it (on every thread) when FindClass does not find them, and
+	each thread attached from now on (and the calling one) has it set
+	as its context class loader.
+
+	JvmConfig.HomeLoader captures the main thread's context class loader
+	at NewJVM;  a nil loader clears the home loader.
+*/
+func (self *JVM) SetHomeLoader(env *Environment, loader *Object) (err error) {
+	if loader != nil {
+		if err = env.setContextClassLoader(loader); err != nil {
+			return
+		}
+		loader = env.NewGlobalRef(loader)
+	}
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if self.home != nil {
+		env.DeleteGlobalRef(self.home)
+	}
+	self.home = loader
+	return
+}
+
+// Makes the loader of class (or, for bootstrap classes, the system class loader) the home loader.
+func (self *JVM) SetHomeLoaderFrom(env *Environment, class *Class) (err error) {
+	loader, err := class.asObject().CallObj(env, false, "getClassLoader", types.Class{classLoaderClass})
+	if err == nil && loader.object == nil {
+		loader, err = env.systemClassLoader()
+	}
+	if err != nil {
+		return
+	}
+	defer env.DeleteLocalRef(loader)
+	return self.SetHomeLoader(env, loader)
+}
+
+// the home loader (a global ref), or nil if none is set
+func (self *JVM) HomeLoader() *Object {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	return self.home
+}
+
+// the home loader as a local ref of env's (safe from SetHomeLoader's deleting it), or nil
+func (self *JVM) localHome(env *Environment) *Object {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if self.home == nil {
+		return nil
+	}
+	return env.NewLocalRef(self.home)
+}
+
+var threadClass = types.Name{"java", "lang", "Thread"}
+
+// Thread.currentThread().setContextClassLoader(loader)
+func (self *Environment) setContextClassLoader(loader *Object) (err error) {
+	tc, err := self.GetClass(threadClass)
+	if err != nil {
+		return
+	}
+	thread, err := tc.CallObj(self, true, "currentThread", types.Class{threadClass})
+	if err != nil {
+		return
+	}
+	defer self.DeleteLocalRef(thread)
+	return thread.CallVoid(self, false, "setContextClassLoader", &CastObject{loader, classLoaderClass})
+}
+
+// Thread.currentThread().getContextClassLoader(), as a local ref
+func (self *Environment) contextClassLoader() (loader *Object, err error) {
+	tc, err := self.GetClass(threadClass)
+	if err != nil {
+		return
+	}
+	thread, err := tc.CallObj(self, true, "currentThread", types.Class{threadClass})
+	if err != nil {
+		return
+	}
+	defer self.DeleteLocalRef(thread)
+	return thread.CallObj(self, false, "getContextClassLoader", types.Class{classLoaderClass})
+}
+
+/*
+	Returns a new (global ref) URLClassLoader over the given jar files and
+	class directories, whose parent is the system class loader;  classes
+	loaded through it (see GetClassFrom) are isolated from those of other
+	such loaders.
+*/
+func (self *Environment) NewClassLoader(paths ...string) (loader *Object, err error) {
+	parent, err := self.systemClassLoader()
+	if err != nil {
+		return
+	}
+	defer self.DeleteLocalRef(parent)
+	return self.newURLClassLoader(parent, paths)
+}
+
+/*
+	Loads (and initializes) the named class through loader, via
+	Class.forName;  unlike GetClass, the class is not cached, and the
+	returned global ref is the caller's to release (see DeleteGlobalClassRef).
+*/
+func (self *Environment) GetClassFrom(loader *Object, klass types.Name) (c *Class, err error) {
+	kl, err := self.classForName(loader, klass)
+	if err != nil {
+		return
+	}
+	c = newClass(C.jclass(C.envNewGlobalRef(self.env, C.jobject(kl))))
+	C.envDeleteLocalRef(self.env, C.jobject(kl))
+	return
+}
+
+// Class.forName(klass, true, loader), as a local ref
+func (self *Environment) classForName(loader *Object, klass types.Name) (kl C.jclass, err error) {
+	cc, err := self.GetClass(ClassClass)
+	if err != nil {
+		return
+	}
+	cobj, err := cc.CallObj(self, true, "forName", types.Class{ClassClass},
+		klass.AsName(), true, &CastObject{loader, classLoaderClass})
+	if err == nil {
+		kl = C.jclass(cobj.object)
+	}
+	return
+}
+
+// ClassLoader.getSystemClassLoader(), as a local ref
+func (self *Environment) systemClassLoader() (loader *Object, err error) {
+	lc, err := self.GetClass(classLoaderClass)
+	if err != nil {
+		return
+	}
+	return lc.CallObj(self, true, "getSystemClassLoader", types.Class{classLoaderClass})
+}
+
+// class.getClassLoader(), as a local ref;  nil for the bootstrap loader
+func (self *Environment) loaderOf(class *Class) (loader *Object, err error) {
+	loader, err = class.asObject().CallObj(self, false, "getClassLoader", types.Class{classLoaderClass})
+	if err == nil && loader.object == nil {
+		loader = nil
+	}
+	return
+}
+
+// a new URLClassLoader(paths, parent), as a global ref
+func (self *Environment) newURLClassLoader(parent *Object, paths []string) (loader *Object, err error) {
+	urls := make([]*Object, 0, len(paths))
+	defer func() { blowStack(self, urls) }()
+	for _, path := range paths {
+		var url *Object
+		if url, err = self.pathURL(path); err != nil {
+			return
+		}
+		urls = append(urls, url)
+	}
+	return self.NewInstanceStr(urlClassLoaderClass.AsPath(),
+		&ObjectArray{urls, urlClass}, &CastObject{parent, classLoaderClass})
+}
+
+// the java/net/URL of a jar file or class directory, as a local ref
+func (self *Environment) pathURL(path string) (url *Object, err error) {
+	file, err := self.NewInstanceStr("java/io/File", path)
+	if err != nil {
+		return
+	}
+	defer self.DeleteGlobalRef(file)
+	uri, err := file.CallObj(self, false, "toURI", types.Class{uriClass})
+	if err != nil {
+		return
+	}
+	defer self.DeleteLocalRef(uri)
+	return uri.CallObj(self, false, "toURL", types.Class{urlClass})
+}
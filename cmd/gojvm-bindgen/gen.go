@@ -0,0 +1,366 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/timob/gojvm/types"
+	"github.com/timob/gojvm/types/classfile"
+	"go/format"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// a class being bound:  its Go type, and the (unexported) name of its gojvm.Binding
+type bound struct {
+	class   *classfile.Class
+	goName  string
+	binding string
+}
+
+type generator struct {
+	buf     bytes.Buffer
+	bound   map[string]*bound
+	globals names
+}
+
+// Go identifiers in use in a scope
+type names map[string]bool
+
+// name, or (if taken) name2, name3 ...;  marked taken
+func (self names) take(name string) string {
+	n := name
+	for i := 2; self[n]; i++ {
+		n = name + strconv.Itoa(i)
+	}
+	self[n] = true
+	return n
+}
+
+/*
+	Generates the Go source of package pkg binding the public classes among
+	classes;  other classes they refer to are left as *gojvm.Object.
+*/
+func generate(pkg string, classes []*classfile.Class) (src []byte, err error) {
+	g := &generator{bound: map[string]*bound{}, globals: names{}}
+	list := []*bound{}
+	simple := map[string]int{}
+	for _, c := range classes {
+		if !bindable(c) {
+			continue
+		}
+		b := &bound{class: c}
+		list = append(list, b)
+		simple[c.Name[len(c.Name)-1]]++
+	}
+	if len(list) == 0 {
+		return nil, errors.New("No public classes to bind")
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].class.Name.AsPath() < list[j].class.Name.AsPath() })
+	for _, b := range list {
+		// the simple name, unless two packages share it
+		parts := b.class.Name
+		if simple[parts[len(parts)-1]] == 1 {
+			parts = parts[len(parts)-1:]
+		}
+		name := ""
+		for _, p := range parts {
+			name += exported(p)
+		}
+		b.goName = g.globals.take(name)
+		b.binding = g.globals.take("bind" + b.goName)
+		g.bound[b.class.Name.AsPath()] = b
+	}
+
+	g.printf("// Code generated by gojvm-bindgen;  DO NOT EDIT.\n\n")
+	g.printf("package %s\n\nimport (\n\t\"github.com/timob/gojvm\"\n)\n", pkg)
+	for _, b := range list {
+		g.class(b)
+	}
+	if src, err = format.Source(g.buf.Bytes()); err != nil {
+		err = fmt.Errorf("Generated invalid Go (%v):\n%s", err, g.buf.Bytes())
+	}
+	return
+}
+
+// true for the classes worth binding:  public ones, but module- and package-info
+func bindable(c *classfile.Class) bool {
+	last := c.Name[len(c.Name)-1]
+	return c.Access.Has(classfile.AccPublic) && !c.Access.Has(classfile.AccSynthetic) &&
+		!c.Access.Has(classfile.AccModule) && last != "package-info" && last != "module-info"
+}
+
+// true for the members worth binding:  public, and not compiler-generated
+func visible(access classfile.Access) bool {
+	return access.Has(classfile.AccPublic) && !access.Has(classfile.AccSynthetic) && !access.Has(classfile.AccBridge)
+}
+
+func (self *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&self.buf, format, args...)
+}
+
+// a Java identifier as an exported Go one
+func exported(s string) string {
+	s = strings.Replace(s, "$", "_", -1)
+	r := []rune(s)
+	r[0] = unicode.ToUpper(r[0])
+	if !unicode.IsUpper(r[0]) {
+		return "J" + string(r)
+	}
+	return string(r)
+}
+
+func (self *generator) class(b *bound) {
+	c := b.class
+	kind := "class"
+	if c.Access.Has(classfile.AccInterface) {
+		kind = "interface"
+	}
+	self.printf("\n// %s (a public %s", c.Name.AsName(), kind)
+	if c.Super != nil && c.Super.AsPath() != types.JavaLangObject.AsPath() {
+		self.printf(" extending %s", c.Super.AsName())
+	}
+	self.printf(")\ntype %s struct {\n\t*gojvm.Object\n}\n\n", b.goName)
+	self.printf("var %s = gojvm.NewBinding(%q)\n", b.binding, c.Name.AsPath())
+
+	members := names{"Object": true}
+	instantiable := !c.Access.Has(classfile.AccAbstract) && !c.Access.Has(classfile.AccInterface)
+	for _, m := range c.Methods {
+		switch {
+		case !visible(m.Access) || m.Name == "<clinit>":
+		case m.Name == "<init>":
+			if instantiable {
+				self.constructor(b, m, self.globals.take("New"+b.goName))
+			}
+		case m.Access.Has(classfile.AccStatic):
+			self.method(b, m, self.globals.take(b.goName+exported(m.Name)), false)
+		default:
+			self.method(b, m, members.take(exported(m.Name)), true)
+		}
+	}
+	for _, f := range c.Fields {
+		switch {
+		case !visible(f.Access):
+		case f.Access.Has(classfile.AccStatic | classfile.AccFinal) && self.constant(b, f):
+		case f.Access.Has(classfile.AccStatic):
+			self.getter(b, f, self.globals.take(b.goName+"Get"+exported(f.Name)), false)
+			if !f.Access.Has(classfile.AccFinal) {
+				self.setter(b, f, self.globals.take(b.goName+"Set"+exported(f.Name)), false)
+			}
+		default:
+			self.getter(b, f, members.take("Get"+exported(f.Name)), true)
+			if !f.Access.Has(classfile.AccFinal) {
+				self.setter(b, f, members.take("Set"+exported(f.Name)), true)
+			}
+		}
+	}
+}
+
+// the Go type of a Java type (as a parameter, if param)
+func (self *generator) goType(t types.Typed, param bool) string {
+	switch t.Kind() {
+	case types.BoolKind:
+		return "bool"
+	case types.ByteKind:
+		return "int8"
+	case types.CharKind:
+		return "uint16"
+	case types.ShortKind:
+		return "int16"
+	case types.IntKind:
+		return "int"
+	case types.LongKind:
+		return "int64"
+	case types.FloatKind:
+		return "float32"
+	case types.DoubleKind:
+		return "float64"
+	case types.ClassKind:
+		name := t.(types.Class).Klass.AsPath()
+		if name == types.JavaLangString.AsPath() {
+			return "string"
+		}
+		if b, ok := self.bound[name]; ok {
+			return b.goName
+		}
+	case types.ArrayKind:
+		if param {
+			return "interface{}" // a Go slice (as Call takes), or an *Object
+		}
+	}
+	return "*gojvm.Object"
+}
+
+// the zero value of the Go type of t (as a result)
+func (self *generator) zero(t types.Typed) string {
+	switch gt := self.goType(t, false); gt {
+	case "bool":
+		return "false"
+	case "string":
+		return `""`
+	case "*gojvm.Object":
+		return "nil"
+	case "int8", "uint16", "int16", "int", "int64", "float32", "float64":
+		return "0"
+	default:
+		return gt + "{}"
+	}
+}
+
+// true if t binds to a generated type (passed, and returned, as its *Object)
+func (self *generator) wrapped(t types.Typed) bool {
+	if c, ok := t.(types.Class); ok {
+		_, ok = self.bound[c.Klass.AsPath()]
+		return ok
+	}
+	return false
+}
+
+// the parameter list ("env *gojvm.Environment, p0 int, ...") and call arguments (", p0, p1.Object") of params
+func (self *generator) params(params []types.Typed) (decl, args string) {
+	decl = "env *gojvm.Environment"
+	for i, p := range params {
+		decl += fmt.Sprintf(", p%d %s", i, self.goType(p, true))
+		if self.wrapped(p) {
+			args += fmt.Sprintf(", p%d.Object", i)
+		} else {
+			args += fmt.Sprintf(", p%d", i)
+		}
+	}
+	return
+}
+
+// the Invoke method (of MethodHandle) for results of the primitive kind k
+var invokeKinds = map[types.Kind]string{
+	types.BoolKind: "InvokeBool", types.ByteKind: "InvokeByte", types.CharKind: "InvokeChar",
+	types.ShortKind: "InvokeShort", types.IntKind: "InvokeInt", types.LongKind: "InvokeLong",
+	types.FloatKind: "InvokeFloat", types.DoubleKind: "InvokeDouble",
+}
+
+func (self *generator) constructor(b *bound, m *classfile.Method, name string) {
+	decl, args := self.params(m.Type.Params)
+	self.printf("\n// new %s%s;  the result is a global ref\n", b.class.Name.AsName(), m.Descriptor)
+	self.printf("func %s(%s) (%s, error) {\n", name, decl, b.goName)
+	self.printf("\th, err := %s.Method(env, \"<init>\", %q)\n", b.binding, m.Descriptor)
+	self.printf("\tif err != nil {\n\t\treturn %s{}, err\n\t}\n", b.goName)
+	self.printf("\to, err := h.New(env%s)\n\treturn %s{o}, err\n}\n", args, b.goName)
+}
+
+func (self *generator) method(b *bound, m *classfile.Method, name string, instance bool) {
+	decl, args := self.params(m.Type.Params)
+	ret, recv := m.Type.Return, "nil"
+	results := "error"
+	if ret.Kind() != types.VoidKind {
+		results = "(" + self.goType(ret, false) + ", error)"
+	}
+	if instance {
+		recv = "self.Object"
+		self.printf("\n// %s%s\n", m.Name, m.Descriptor)
+		self.printf("func (self %s) %s(%s) %s {\n", b.goName, name, decl, results)
+	} else {
+		self.printf("\n// static %s.%s%s\n", b.class.Name.AsName(), m.Name, m.Descriptor)
+		self.printf("func %s(%s) %s {\n", name, decl, results)
+	}
+	self.printf("\th, err := %s.Method(env, %q, %q)\n", b.binding, m.Name, m.Descriptor)
+	if ret.Kind() == types.VoidKind {
+		self.printf("\tif err != nil {\n\t\treturn err\n\t}\n")
+		self.printf("\treturn h.InvokeVoid(env, %s%s)\n}\n", recv, args)
+		return
+	}
+	self.printf("\tif err != nil {\n\t\treturn %s, err\n\t}\n", self.zero(ret))
+	call := fmt.Sprintf("h.InvokeObj(env, %s%s)", recv, args)
+	switch gt := self.goType(ret, false); {
+	case invokeKinds[ret.Kind()] != "":
+		self.printf("\treturn h.%s(env, %s%s)\n", invokeKinds[ret.Kind()], recv, args)
+	case gt == "string":
+		self.printf("\treturn env.StringResult(%s)\n", call)
+	case self.wrapped(ret):
+		self.printf("\to, err := %s\n\treturn %s{o}, err\n", call, gt)
+	default:
+		self.printf("\treturn %s\n", call)
+	}
+	self.printf("}\n")
+}
+
+/*
+	Emits a static final field with a ConstantValue as a Go const;  false if
+	it has none (or one Go can't express, such as NaN).
+*/
+func (self *generator) constant(b *bound, f *classfile.Field) bool {
+	var lit string
+	switch v := f.Constant.(type) {
+	case int32:
+		lit = strconv.Itoa(int(v))
+		if f.Type.Kind() == types.BoolKind {
+			lit = strconv.FormatBool(v != 0)
+		}
+	case int64:
+		lit = strconv.FormatInt(v, 10)
+	case float32:
+		if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+			return false
+		}
+		lit = strconv.FormatFloat(float64(v), 'g', -1, 32)
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return false
+		}
+		lit = strconv.FormatFloat(v, 'g', -1, 64)
+	case string:
+		lit = strconv.Quote(v)
+	default:
+		return false
+	}
+	self.printf("\n// %s.%s\nconst %s %s = %s\n", b.class.Name.AsName(), f.Name,
+		self.globals.take(b.goName+exported(f.Name)), self.goType(f.Type, false), lit)
+	return true
+}
+
+func (self *generator) getter(b *bound, f *classfile.Field, name string, instance bool) {
+	gt, recv := self.goType(f.Type, false), "nil"
+	if instance {
+		recv = "self.Object"
+		self.printf("\n// the field %s %s\n", f.Name, f.Descriptor)
+		self.printf("func (self %s) %s(env *gojvm.Environment) (%s, error) {\n", b.goName, name, gt)
+	} else {
+		self.printf("\n// the static field %s.%s %s\n", b.class.Name.AsName(), f.Name, f.Descriptor)
+		self.printf("func %s(env *gojvm.Environment) (%s, error) {\n", name, gt)
+	}
+	self.printf("\tf, err := %s.Field(env, %q, %q)\n", b.binding, f.Name, f.Descriptor)
+	self.printf("\tif err != nil {\n\t\treturn %s, err\n\t}\n", self.zero(f.Type))
+	call := fmt.Sprintf("f.GetObj(env, %s)", recv)
+	switch k := f.Type.Kind(); {
+	case k != types.ClassKind && k != types.ArrayKind:
+		self.printf("\tv, err := f.Get(env, %s)\n", recv)
+		self.printf("\tif err != nil {\n\t\treturn %s, err\n\t}\n", self.zero(f.Type))
+		self.printf("\treturn v.(%s), nil\n", gt)
+	case gt == "string":
+		self.printf("\treturn env.StringResult(%s)\n", call)
+	case self.wrapped(f.Type):
+		self.printf("\to, err := %s\n\treturn %s{o}, err\n", call, gt)
+	default:
+		self.printf("\treturn %s\n", call)
+	}
+	self.printf("}\n")
+}
+
+func (self *generator) setter(b *bound, f *classfile.Field, name string, instance bool) {
+	gt, recv, arg := self.goType(f.Type, true), "nil", "v"
+	if self.wrapped(f.Type) {
+		arg = "v.Object"
+	}
+	if instance {
+		recv = "self.Object"
+		self.printf("\n// sets the field %s %s\n", f.Name, f.Descriptor)
+		self.printf("func (self %s) %s(env *gojvm.Environment, v %s) error {\n", b.goName, name, gt)
+	} else {
+		self.printf("\n// sets the static field %s.%s %s\n", b.class.Name.AsName(), f.Name, f.Descriptor)
+		self.printf("func %s(env *gojvm.Environment, v %s) error {\n", name, gt)
+	}
+	self.printf("\tf, err := %s.Field(env, %q, %q)\n", b.binding, f.Name, f.Descriptor)
+	self.printf("\tif err != nil {\n\t\treturn err\n\t}\n")
+	self.printf("\treturn f.Set(env, %s, %s)\n}\n", recv, arg)
+}
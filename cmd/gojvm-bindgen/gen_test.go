@@ -0,0 +1,100 @@
+package main
+
+import (
+	"github.com/timob/gojvm/types/classfile"
+	"strings"
+	"testing"
+)
+
+/* Bindings generated for classes from the classfile Writer;
+
+Verifies:
+	Classes bind to structs, constructors to NewX, overloads are numbered
+	Bound classes map to their structs, String to string, primitives to Go's
+	Static methods and fields bind to package funcs, constants to consts
+	Non-public classes and members are left out
+*/
+
+func fatalIf(t *testing.T, cond bool, msg string, args ...interface{}) {
+	if cond {
+		t.Fatalf(msg, args...)
+	}
+}
+
+func parsed(t *testing.T, data []byte) *classfile.Class {
+	c, err := classfile.Parse(data)
+	fatalIf(t, err != nil, "Couldn't parse a written class: %v", err)
+	return c
+}
+
+func sampleClasses(t *testing.T) []*classfile.Class {
+	w := classfile.NewWriter()
+	w.DefaultConstructor("java/lang/Object")
+	w.NativeMethod(classfile.AccPublic|classfile.AccNative, "<init>", "(Ljava/lang/String;I)V")
+	w.NativeMethod(classfile.AccPublic|classfile.AccNative, "add", "(I)Z")
+	w.NativeMethod(classfile.AccPublic|classfile.AccNative, "add", "(Lorg/example/Counter;)Z")
+	w.NativeMethod(classfile.AccPublic|classfile.AccNative, "name", "()Ljava/lang/String;")
+	w.NativeMethod(classfile.AccPublic|classfile.AccNative, "next", "()Lorg/example/Counter;")
+	w.NativeMethod(classfile.AccPublic|classfile.AccNative, "reset", "([J)V")
+	w.NativeMethod(classfile.AccPublic|classfile.AccStatic|classfile.AccNative, "of", "(J)Lorg/example/Counter;")
+	w.NativeMethod(classfile.AccPrivate|classfile.AccNative, "secret", "()V")
+	w.ConstantField(classfile.AccPublic, "LIMIT", "J", w.Number(int64(1)<<40))
+	w.ConstantField(classfile.AccPublic, "LABEL", "Ljava/lang/String;", w.String("a \"counter\""))
+	w.ConstantField(classfile.AccPublic, "ON", "Z", w.Number(int32(1)))
+	w.ConstantField(classfile.AccPublic, "NAN", "D", w.Number(0.0/zero))
+	w.Field(classfile.AccPublic, "count", "I")
+	w.Field(classfile.AccPublic|classfile.AccFinal, "parent", "Lorg/example/Counter;")
+	w.Field(classfile.AccPublic|classfile.AccStatic, "total", "Ljava/lang/Object;")
+	counter := w.Bytes(classfile.AccPublic|classfile.AccSuper, "org/example/Counter", "java/lang/Object")
+
+	w = classfile.NewWriter()
+	w.NativeMethod(classfile.AccPublic|classfile.AccAbstract, "count", "()I")
+	countable := w.Bytes(classfile.AccPublic|classfile.AccInterface|classfile.AccAbstract, "org/example/Countable", "java/lang/Object")
+
+	w = classfile.NewWriter()
+	w.DefaultConstructor("java/lang/Object")
+	hidden := w.Bytes(classfile.AccSuper, "org/example/Hidden", "java/lang/Object")
+	return []*classfile.Class{parsed(t, counter), parsed(t, countable), parsed(t, hidden)}
+}
+
+var zero = 0.0
+
+func TestGenerate(t *testing.T) {
+	src, err := generate("example", sampleClasses(t))
+	fatalIf(t, err != nil, "Couldn't generate: %v", err)
+	s := string(src)
+	for _, expected := range []string{
+		"package example",
+		"type Counter struct {\n\t*gojvm.Object\n}",
+		`var bindCounter = gojvm.NewBinding("org/example/Counter")`,
+		"func NewCounter(env *gojvm.Environment) (Counter, error) {",
+		"func NewCounter2(env *gojvm.Environment, p0 string, p1 int) (Counter, error) {",
+		`h, err := bindCounter.Method(env, "<init>", "(Ljava/lang/String;I)V")`,
+		"func (self Counter) Add(env *gojvm.Environment, p0 int) (bool, error) {",
+		"return h.InvokeBool(env, self.Object, p0)",
+		"func (self Counter) Add2(env *gojvm.Environment, p0 Counter) (bool, error) {",
+		"return h.InvokeBool(env, self.Object, p0.Object)",
+		"func (self Counter) Name(env *gojvm.Environment) (string, error) {",
+		"return env.StringResult(h.InvokeObj(env, self.Object))",
+		"func (self Counter) Next(env *gojvm.Environment) (Counter, error) {",
+		"func (self Counter) Reset(env *gojvm.Environment, p0 interface{}) error {",
+		"func CounterOf(env *gojvm.Environment, p0 int64) (Counter, error) {",
+		"const CounterLIMIT int64 = 1099511627776",
+		`const CounterLABEL string = "a \"counter\""`,
+		"const CounterON bool = true",
+		"func CounterGetNAN(env *gojvm.Environment) (float64, error) {",
+		"func (self Counter) GetCount(env *gojvm.Environment) (int, error) {",
+		"return v.(int), nil",
+		"func (self Counter) SetCount(env *gojvm.Environment, v int) error {",
+		"func (self Counter) GetParent(env *gojvm.Environment) (Counter, error) {",
+		"func CounterGetTotal(env *gojvm.Environment) (*gojvm.Object, error) {",
+		"func CounterSetTotal(env *gojvm.Environment, v *gojvm.Object) error {",
+		"type Countable struct {",
+		"func (self Countable) Count(env *gojvm.Environment) (int, error) {",
+	} {
+		fatalIf(t, !strings.Contains(s, expected), "Missing %q in:\n%s", expected, s)
+	}
+	for _, unexpected := range []string{"Secret", "SetParent", "Hidden", "NewCountable"} {
+		fatalIf(t, strings.Contains(s, unexpected), "Unexpected %q in:\n%s", unexpected, s)
+	}
+}
@@ -0,0 +1,82 @@
+/*
+	gojvm-bindgen generates typed Go wrappers of the public classes of jars
+	(or class directories), read without a JVM:
+
+		gojvm-bindgen -pkg jutil -classes java/util/ -o jutil/bindings.go rt.jar
+
+	Each class becomes a struct embedding its *gojvm.Object, with methods for
+	its public instance methods and fields (GetX/SetX), and package-level
+	funcs for its constructors (NewX), static methods (XName) and static
+	fields (XGetName/XSetName);  constant fields become Go consts.  Overloads
+	are numbered (Add, Add2, ...) in class file order, and each wrapper holds
+	the method's descriptor, resolved to a handle on first use.
+
+	Primitives map to the Go types of the Call* API, java.lang.String to
+	string, other bound classes to their structs, and anything else to
+	*gojvm.Object (array parameters take interface{}:  a Go slice, or an
+	*Object).  Results are local refs, but for constructors' (global refs).
+*/
+package main
+
+import (
+	"flag"
+	"github.com/timob/gojvm/types/classfile"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+)
+
+var pkg = "bindings"
+var output = ""
+var prefixes = ""
+
+func init() {
+	flag.StringVar(&pkg, "pkg", pkg, "Package name of the generated source")
+	flag.StringVar(&output, "o", output, "Output file (default stdout)")
+	flag.StringVar(&prefixes, "classes", prefixes, "Comma separated prefixes of the (internal) class names to bind, e.g., java/util/,java/io/File")
+}
+
+func main() {
+	flag.Parse()
+	if flag.NArg() == 0 {
+		log.Fatalf("Expected: gojvm-bindgen [flags] jar-or-class-dir ...")
+	}
+	classes := []*classfile.Class{}
+	for _, path := range flag.Args() {
+		read, err := classfile.Read(path)
+		if err != nil {
+			log.Fatalf("Couldn't read classes: %v", err)
+		}
+		for _, c := range read {
+			if selected(c) {
+				classes = append(classes, c)
+			}
+		}
+	}
+	src, err := generate(pkg, classes)
+	if err != nil {
+		log.Fatalf("Couldn't generate bindings: %v", err)
+	}
+	if output == "" {
+		_, err = os.Stdout.Write(src)
+	} else {
+		err = ioutil.WriteFile(output, src, 0644)
+	}
+	if err != nil {
+		log.Fatalf("Couldn't write bindings: %v", err)
+	}
+}
+
+// true if c is among the -classes prefixes (or there are none)
+func selected(c *classfile.Class) bool {
+	if prefixes == "" {
+		return true
+	}
+	for _, p := range strings.Split(prefixes, ",") {
+		if p != "" && strings.HasPrefix(c.Name.AsPath(), p) {
+			return true
+		}
+	}
+	return false
+}
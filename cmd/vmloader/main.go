@@ -23,7 +23,7 @@ func main(){
 		log.Fatalf("Expected: %s 'class-with-main'", "vmloader")
 	}
 	print("Initializing VM\n")
- 	_, env, err := gojvm.NewJVM(0, gojvm.JvmConfig{[]string{cpBase,jrePath}})
+ 	_, env, err := gojvm.NewJVM(0, gojvm.JvmConfig{ClassPath: []string{cpBase,jrePath}})
  	if err != nil {
 		log.Fatalf("err == %s", err)
 	}
@@ -1,8 +1,7 @@
 package gojvm
 
 //#cgo CFLAGS:-Iinclude
-//#cgo LDFLAGS:-ljvm	-L/usr/lib/jvm/default-java/jre/lib/amd64/server
-//#include</usr/lib/jvm/default-java/include/jni.h>
+//#include<jni.h>
 //#include <stdlib.h>
 //#include <unistd.h>
 //#include "helpers.h"
@@ -12,11 +11,23 @@ const (
 	JNI_VERSION_1_2 = C.JNI_VERSION_1_2
 	JNI_VERSION_1_4 = C.JNI_VERSION_1_4
 	JNI_VERSION_1_6 = C.JNI_VERSION_1_6
+	// spelled out, as older jni.h headers lack them
+	JNI_VERSION_1_8 = 0x00010008
+	JNI_VERSION_9   = 0x00090000
+	JNI_VERSION_10  = 0x000a0000
+	JNI_VERSION_19  = 0x00130000
+	JNI_VERSION_20  = 0x00140000
+	JNI_VERSION_21  = 0x00150000
 )
 
-const DEFAULT_JVM_VERSION = JNI_VERSION_1_6
+const DEFAULT_JVM_VERSION = JNI_VERSION_1_8
 
+// (JDK 9 and later need no JRE class path entry;  the runtime classes are
+// in the modules image, and a missing directory is harmless on the path.
+// The JVM itself is located by FindLibJVM.)
 const SystemDefaultJREPath = "/usr/lib/jvm/default-java/jre/lib"
+
+// Deprecated: the Sun Java 6 layout;  FindLibJVM locates installed JDKs.
 const SunJREPath = "/usr/lib/jvm/java-6-sun/jre/lib"
 
 var DefaultJREPath = SystemDefaultJREPath
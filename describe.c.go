@@ -0,0 +1,273 @@
+package gojvm
+
+//#include "helpers.h"
+import "C"
+import (
+	"github.com/timob/gojvm/types"
+)
+
+var reflectFieldClass = types.Name{"java", "lang", "reflect", "Field"}
+
+/*
+	The shape of a class, as reported by java.lang.reflect (see Describe).
+	Superclass is empty for java/lang/Object, interfaces and primitives.
+*/
+type ClassInfo struct {
+	Name         types.Name
+	Superclass   types.Name
+	Interfaces   []types.Name
+	Modifiers    Modifiers
+	Constructors []MethodInfo
+	Methods      []MethodInfo
+	Fields       []FieldInfo
+}
+
+/*
+	A method (or constructor, named "<init>") of a described class;  Handle
+	is resolved from the reflected method, and is ready to invoke.
+*/
+type MethodInfo struct {
+	Name           string
+	Signature      types.MethodSignature
+	Modifiers      Modifiers
+	DeclaringClass types.Name
+	Handle         *MethodHandle
+}
+
+// A field of a described class;  Handle is resolved from the reflected field.
+type FieldInfo struct {
+	Name           string
+	Type           types.Typed
+	Modifiers      Modifiers
+	DeclaringClass types.Name
+	Handle         *FieldHandle
+}
+
+/*
+	Describes class via java.lang.reflect:  its superclass, interfaces and
+	modifiers, its declared constructors, and both its public (including
+	inherited) and declared (including private) methods and fields.
+
+	Each handle in the result holds a global ref of class;  release them all
+	with ClassInfo.Release once the handles are no longer needed.
+*/
+func (self *Environment) Describe(class *Class) (info *ClassInfo, err error) {
+	cobj := class.asObject()
+	described := &ClassInfo{}
+	info = described
+	defer func() {
+		if err != nil {
+			described.Release(self)
+		}
+	}()
+	if info.Name, err = class.GetName(self); err != nil {
+		return nil, err
+	}
+	mods, err := cobj.CallInt(self, false, "getModifiers")
+	if err != nil {
+		return nil, err
+	}
+	info.Modifiers = Modifiers(mods)
+
+	super, err := cobj.CallObj(self, false, "getSuperclass", types.Class{ClassClass})
+	if err != nil {
+		return nil, err
+	}
+	if super.object != nil {
+		info.Superclass, err = self.classObjectName(super)
+		self.DeleteLocalRef(super)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = self.eachReflected(cobj, "getInterfaces", types.Class{ClassClass}, func(iface *Object) (err error) {
+		var name types.Name
+		if name, err = self.classObjectName(iface); err == nil {
+			info.Interfaces = append(info.Interfaces, name)
+		}
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = self.eachReflected(cobj, "getDeclaredConstructors", types.Class{reflectConstructorClass}, func(m *Object) (err error) {
+		var mi MethodInfo
+		if mi, err = self.methodInfo(class, m, true); err == nil {
+			info.Constructors = append(info.Constructors, mi)
+		}
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	for _, list := range []string{"getMethods", "getDeclaredMethods"} {
+		err = self.eachReflected(cobj, list, types.Class{reflectMethodClass}, func(m *Object) (err error) {
+			var mi MethodInfo
+			if mi, err = self.methodInfo(class, m, false); err == nil {
+				key := mi.DeclaringClass.AsPath() + "." + mi.Name + mi.Signature.String()
+				if !seen[key] {
+					seen[key] = true
+					info.Methods = append(info.Methods, mi)
+				} else {
+					mi.Handle.Release(self)
+				}
+			}
+			return
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, list := range []string{"getFields", "getDeclaredFields"} {
+		err = self.eachReflected(cobj, list, types.Class{reflectFieldClass}, func(f *Object) (err error) {
+			var fi FieldInfo
+			if fi, err = self.fieldInfo(class, f); err == nil {
+				key := fi.DeclaringClass.AsPath() + "." + fi.Name
+				if !seen[key] {
+					seen[key] = true
+					info.Fields = append(info.Fields, fi)
+				} else {
+					fi.Handle.Release(self)
+				}
+			}
+			return
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+// calls the array-returning niladic method mname on obj, and passes each element to fn
+// (releasing them after);  iteration stops at the first error.
+func (self *Environment) eachReflected(obj *Object, mname string, elem types.Typed, fn func(*Object) error) (err error) {
+	arr, err := obj.CallObj(self, false, mname, types.Array{elem})
+	if err != nil {
+		return
+	}
+	defer self.DeleteLocalRef(arr)
+	items := self.ToObjectArray(arr)
+	defer blowStack(self, items)
+	for _, item := range items {
+		if err = fn(item); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// the types.Name of a java/lang/Class object
+func (self *Environment) classObjectName(cobj *Object) (name types.Name, err error) {
+	cname, _, err := cobj.CallString(self, false, "getName")
+	if err == nil {
+		name = types.NewName(cname)
+	}
+	return
+}
+
+// the modifiers and declaring class common to reflected members
+func (self *Environment) memberInfo(member *Object) (mods Modifiers, declaring types.Name, err error) {
+	m, err := member.CallInt(self, false, "getModifiers")
+	if err != nil {
+		return
+	}
+	mods = Modifiers(m)
+	dobj, err := member.CallObj(self, false, "getDeclaringClass", types.Class{ClassClass})
+	if err != nil {
+		return
+	}
+	defer self.DeleteLocalRef(dobj)
+	declaring, err = self.classObjectName(dobj)
+	return
+}
+
+// builds the MethodInfo of a reflected Method (or Constructor, if ctor)
+func (self *Environment) methodInfo(class *Class, m *Object, ctor bool) (mi MethodInfo, err error) {
+	mi.Modifiers, mi.DeclaringClass, err = self.memberInfo(m)
+	if err != nil {
+		return
+	}
+	if ctor {
+		mi.Name, mi.Signature.Return = "<init>", types.Basic(types.VoidKind)
+	} else {
+		if mi.Name, _, err = m.CallString(self, false, "getName"); err != nil {
+			return
+		}
+		if mi.Signature.Return, err = self.reflectedClassType(m, "getReturnType"); err != nil {
+			return
+		}
+	}
+	mi.Signature.Params = []types.Typed{}
+	err = self.eachReflected(m, "getParameterTypes", types.Class{ClassClass}, func(p *Object) (err error) {
+		var t types.Typed
+		if t, err = self.classObjectType(p); err == nil {
+			mi.Signature.Params = append(mi.Signature.Params, t)
+		}
+		return
+	})
+	if err != nil {
+		return
+	}
+	id := C.envFromReflectedMethod(self.env, m.object)
+	if id == nil {
+		return mi, self.ExceptionOccurred()
+	}
+	mi.Handle = &MethodHandle{self.globalClass(class), &Method{id, mi.Signature}, mi.Name, mi.Modifiers.Has(ModStatic)}
+	return
+}
+
+// builds the FieldInfo of a reflected Field
+func (self *Environment) fieldInfo(class *Class, f *Object) (fi FieldInfo, err error) {
+	fi.Modifiers, fi.DeclaringClass, err = self.memberInfo(f)
+	if err != nil {
+		return
+	}
+	if fi.Name, _, err = f.CallString(self, false, "getName"); err != nil {
+		return
+	}
+	if fi.Type, err = self.reflectedClassType(f, "getType"); err != nil {
+		return
+	}
+	id := C.envFromReflectedField(self.env, f.object)
+	if id == nil {
+		return fi, self.ExceptionOccurred()
+	}
+	fi.Handle = &FieldHandle{self.globalClass(class), &Field{id}, fi.Name, fi.Type, fi.Modifiers.Has(ModStatic)}
+	return
+}
+
+// Releases the handles of the described constructors, methods and fields
+func (self *ClassInfo) Release(env *Environment) {
+	for _, list := range [][]MethodInfo{self.Constructors, self.Methods} {
+		for _, mi := range list {
+			mi.Handle.Release(env)
+		}
+	}
+	for _, fi := range self.Fields {
+		fi.Handle.Release(env)
+	}
+}
+
+// returns the named method (the first, if overloaded), and whether there was one
+func (self *ClassInfo) Method(name string) (mi MethodInfo, ok bool) {
+	for _, mi = range self.Methods {
+		if mi.Name == name {
+			return mi, true
+		}
+	}
+	return MethodInfo{}, false
+}
+
+// returns the named field, and whether there was one
+func (self *ClassInfo) Field(name string) (fi FieldInfo, ok bool) {
+	for _, fi = range self.Fields {
+		if fi.Name == name {
+			return fi, true
+		}
+	}
+	return FieldInfo{}, false
+}
@@ -0,0 +1,121 @@
+package gojvm
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// libjvm's file name on this platform
+func libJVMName() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return "libjvm.dylib"
+	case "windows":
+		return "jvm.dll"
+	}
+	return "libjvm.so"
+}
+
+// the arch directory of pre-9 JRE layouts (jre/lib/<arch>/server)
+func jreArch() string {
+	switch runtime.GOARCH {
+	case "386":
+		return "i386"
+	case "arm64":
+		return "aarch64"
+	}
+	return runtime.GOARCH
+}
+
+/*
+	Returns the libjvm of the JDK or JRE installed at home, trying the
+	layouts of Java 9 and later (lib/server), then those of Java 8 and
+	earlier (jre/lib/<arch>/server);  "" if there is none.
+*/
+func homeLibJVM(home string) string {
+	if home == "" {
+		return ""
+	}
+	name := libJVMName()
+	candidates := []string{
+		filepath.Join(home, "lib", "server", name),
+		filepath.Join(home, "jre", "lib", jreArch(), "server", name),
+		filepath.Join(home, "lib", jreArch(), "server", name),
+		filepath.Join(home, "jre", "lib", "server", name),
+		filepath.Join(home, "bin", "server", name),
+		filepath.Join(home, "lib", "client", name),
+	}
+	for _, c := range candidates {
+		if info, err := os.Stat(c); err == nil && !info.IsDir() {
+			return c
+		}
+	}
+	return ""
+}
+
+// asks the java on the PATH where it lives (its java.home property)
+func probeJavaHome() string {
+	out, err := exec.Command("java", "-XshowSettings:properties", "-version").CombinedOutput()
+	if err != nil {
+		return ""
+	}
+	return parseJavaHome(string(out))
+}
+
+// finds the "java.home = ..." line of -XshowSettings:properties output
+func parseJavaHome(settings string) string {
+	for _, line := range strings.Split(settings, "\n") {
+		kv := strings.SplitN(strings.TrimSpace(line), "=", 2)
+		if len(kv) == 2 && strings.TrimSpace(kv[0]) == "java.home" {
+			return strings.TrimSpace(kv[1])
+		}
+	}
+	return ""
+}
+
+// where JDKs are commonly installed, most specific first
+var commonJavaHomes = []string{
+	"/usr/lib/jvm/default-java",
+	"/usr/lib/jvm/default",
+	"/usr/lib/jvm/java",
+	"/usr/lib/jvm/*",
+	"/usr/java/latest",
+	"/usr/java/*",
+	"/opt/java/openjdk",
+	"/opt/homebrew/opt/openjdk/libexec/openjdk.jdk/Contents/Home",
+	"/usr/local/opt/openjdk/libexec/openjdk.jdk/Contents/Home",
+	"/Library/Java/JavaVirtualMachines/*/Contents/Home",
+}
+
+/*
+	Locates the libjvm NewJVM loads:  that of conf.JavaHome if set, else of
+	$JAVA_HOME, else of the java found on the PATH (as reported by
+	-XshowSettings), else of the first JDK found in the common install
+	locations.
+*/
+func FindLibJVM(conf JvmConfig) (path string, err error) {
+	if conf.JavaHome != "" {
+		if path = homeLibJVM(conf.JavaHome); path == "" {
+			err = ErrNoLibJVM
+		}
+		return
+	}
+	if path = homeLibJVM(os.Getenv("JAVA_HOME")); path != "" {
+		return
+	}
+	if path = homeLibJVM(probeJavaHome()); path != "" {
+		return
+	}
+	for _, pattern := range commonJavaHomes {
+		homes, _ := filepath.Glob(pattern)
+		for _, home := range homes {
+			if path = homeLibJVM(home); path != "" {
+				return
+			}
+		}
+	}
+	return "", ErrNoLibJVM
+}
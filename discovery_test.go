@@ -0,0 +1,47 @@
+package gojvm
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// makes an empty libjvm at rel under a new temporary java home
+func fakeJavaHome(t *testing.T, rel ...string) (home, lib string) {
+	home, err := ioutil.TempDir("", "gojvm-home")
+	fatalIf(t, err != nil, "Couldn't make java home: %v", err)
+	lib = filepath.Join(append([]string{home}, rel...)...)
+	fatalIf(t, os.MkdirAll(filepath.Dir(lib), 0755) != nil, "Couldn't make %s", filepath.Dir(lib))
+	fatalIf(t, ioutil.WriteFile(lib, nil, 0644) != nil, "Couldn't write %s", lib)
+	return
+}
+
+func TestHomeLibJVM(t *testing.T) {
+	home, lib := fakeJavaHome(t, "lib", "server", libJVMName())
+	defer os.RemoveAll(home)
+	fatalInEq(t, lib, homeLibJVM(home), "Wrong modern libjvm")
+
+	old, oldLib := fakeJavaHome(t, "jre", "lib", jreArch(), "server", libJVMName())
+	defer os.RemoveAll(old)
+	fatalInEq(t, oldLib, homeLibJVM(old), "Wrong Java 8 libjvm")
+
+	fatalInEq(t, "", homeLibJVM(filepath.Join(home, "missing")), "Missing home has no libjvm")
+}
+
+func TestFindLibJVMJavaHome(t *testing.T) {
+	home, lib := fakeJavaHome(t, "lib", "server", libJVMName())
+	defer os.RemoveAll(home)
+	path, err := FindLibJVM(JvmConfig{JavaHome: home})
+	fatalIf(t, err != nil, "Couldn't find libjvm: %v", err)
+	fatalInEq(t, lib, path, "Wrong libjvm")
+
+	_, err = FindLibJVM(JvmConfig{JavaHome: filepath.Join(home, "missing")})
+	fatalIf(t, err != ErrNoLibJVM, "An explicit JavaHome without libjvm should fail (got %v)", err)
+}
+
+func TestParseJavaHome(t *testing.T) {
+	settings := "Property settings:\n    file.encoding = UTF-8\n    java.home = /opt/jdk-21\n    java.version = 21\n"
+	fatalInEq(t, "/opt/jdk-21", parseJavaHome(settings), "Wrong java.home")
+	fatalInEq(t, "", parseJavaHome("no settings"), "Unexpected java.home")
+}
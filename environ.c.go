@@ -1,7 +1,6 @@
 package gojvm
 
 //#cgo CFLAGS:-I../include/
-//#cgo LDFLAGS:-ljvm	-L/usr/lib/jvm/default-java/jre/lib/amd64/server
 //#include "helpers.h"
 import "C"
 import (
@@ -29,6 +28,7 @@ type Environment struct {
 	env             *C.JNIEnv
 	jvm             *JVM
 	classes         map[string]*Class
+	overloads       map[string]types.MethodSignature
 	quietExceptions bool
 	// various 'consts'
 	_UTF8 C.jstring // "UTF8" parameter
@@ -45,7 +45,7 @@ func (self *Environment) getObjectMethod(obj *Object, static bool, mname string,
 	if err != nil {
 		return
 	}
-	args, objList, err = newArgList(self, params...)
+	args, objList, err = newMethodArgList(self, meth, params...)
 	return
 }
 
@@ -96,10 +96,15 @@ func (self *Environment) getClassMethod(c *Class, static bool, mname string, rTy
 	if err != nil {
 		return
 	}
-	args, objList, err = newArgList(self, params...)
+	args, objList, err = newMethodArgList(self, meth, params...)
 	return
 }
 
+// Returns the JNI version of the running JVM (e.g., JNI_VERSION_21 on Java 21)
+func (self *Environment) Version() int {
+	return int(C.envGetVersion(self.env))
+}
+
 // (Un)Suppress the java console barf of exceptions
 // (execeptions are still caught, cleared and returned)
 func (self *Environment) Mute(mute bool) { self.quietExceptions = mute }
@@ -122,6 +127,7 @@ func NewEnvironment(jvm *JVM) *Environment {
 	return &Environment{
 		env:     new(C.JNIEnv),
 		classes: map[string]*Class{},
+		overloads: map[string]types.MethodSignature{},
 		jvm:     jvm,
 		quietExceptions: true,
 	}
@@ -134,6 +140,13 @@ it is useless.  It (appears) to be an error to ref/unref methods.
 */
 type Method struct {
 	method C.jmethodID
+	sig    types.MethodSignature
+}
+
+// true if the resolved method returns a primitive wrapper in place of
+// the primitive that was asked for (which the caller must unbox).
+func (self *Method) boxedReturn() bool {
+	return self.sig.Return != nil && self.sig.Return.Kind() == types.ClassKind
 }
 
 func (self *Environment) findCachedClass(klass types.Name) (c *Class, err error) {
@@ -187,6 +200,53 @@ func (self *Environment) newByteObject(bts []byte) (o *Object, err error) {
 	return
 }
 
+// (Go ints are narrowed to Java's 32 bits)
+func (self *Environment) newIntObject(ints []int) (o *Object, err error) {
+	ja := C.envNewIntArray(self.env, C.jsize(len(ints)))
+	if ja == nil {
+		err = errors.New("Error allocating int array")
+	}
+	if err == nil && len(ints) > 0 {
+		jints := make([]int32, len(ints))
+		for i, v := range ints {
+			jints[i] = int32(v)
+		}
+		C.envSetIntArrayRegion(self.env, ja, 0, C.jsize(len(jints)), unsafe.Pointer(&jints[0]))
+	}
+	if err == nil {
+		o = newObject(C.jobject(ja))
+	}
+	return
+}
+
+func (self *Environment) newLongObject(longs []int64) (o *Object, err error) {
+	ja := C.envNewLongArray(self.env, C.jsize(len(longs)))
+	if ja == nil {
+		err = errors.New("Error allocating long array")
+	}
+	if err == nil && len(longs) > 0 {
+		C.envSetLongArrayRegion(self.env, ja, 0, C.jsize(len(longs)), unsafe.Pointer(&longs[0]))
+	}
+	if err == nil {
+		o = newObject(C.jobject(ja))
+	}
+	return
+}
+
+func (self *Environment) newCharObject(chars []uint16) (o *Object, err error) {
+	ja := C.envNewCharArray(self.env, C.jsize(len(chars)))
+	if ja == nil {
+		err = errors.New("Error allocating char array")
+	}
+	if err == nil && len(chars) > 0 {
+		C.envSetCharArrayRegion(self.env, ja, 0, C.jsize(len(chars)), unsafe.Pointer(&chars[0]))
+	}
+	if err == nil {
+		o = newObject(C.jobject(ja))
+	}
+	return
+}
+
 /* 
 	returns a new *Object of the class named by 'klass' (Wrapper around NewInstance(types.NewName(...)))
 */
@@ -233,6 +293,23 @@ func (self *Environment) GetClass(klass types.Name) (c *Class, err error) {
 	defer C.free(unsafe.Pointer(s))
 	// print("envFindClass ", klass,"\n")
 	kl := C.envFindClass(self.env, s)
+	if kl == nil && self.jvm != nil {
+		// not found by the thread's loader;  try the JVM's home loader, then the
+		// entries added by AddClassPath
+		if loaders := self.jvm.fallbackLoaders(); len(loaders) > 0 {
+			C.envExceptionClear(self.env)
+			unmute := self.defMute()
+			for _, loader := range loaders {
+				if kl, err = self.classForName(loader, klass); kl != nil {
+					break
+				}
+			}
+			unmute()
+			if kl == nil {
+				return nil, err
+			}
+		}
+	}
 	if kl == nil {
 		//print("GetClass missed ", klass.AsPath(), "\n\n")
 		err = self.ExceptionOccurred()
@@ -246,6 +323,48 @@ func (self *Environment) GetClass(klass types.Name) (c *Class, err error) {
 	return
 }
 
+/*
+	Defines the class klass from the class file bytes in bytecode, in loader
+	(or, if loader is nil, the system class loader, so the class can see the
+	class path).  This lets a program carry its Java helpers with it (e.g.,
+	via go:embed) rather than needing them on the class path.
+
+	The returned class is a global ref of the caller's own, to release with
+	DeleteGlobalClassRef.  A class defined in the system class loader is also
+	cached (under a ref of its own) as GetClass caches, so later GetClass
+	calls for klass find it;  one defined in another loader is not (its name
+	may mean another class elsewhere).  Defining the same class twice in a
+	loader is an error (a LinkageError is raised by the JVM).
+*/
+func (self *Environment) DefineClass(klass types.Name, loader *Object, bytecode []byte) (c *Class, err error) {
+	system := loader == nil
+	if system {
+		if loader, err = self.systemClassLoader(); err != nil {
+			return
+		}
+		defer self.DeleteLocalRef(loader)
+	}
+	var buf unsafe.Pointer
+	if len(bytecode) > 0 {
+		buf = unsafe.Pointer(&bytecode[0])
+	}
+	s := C.CString(klass.AsPath())
+	defer C.free(unsafe.Pointer(s))
+	kl := C.envDefineClass(self.env, s, loader.object, buf, C.jsize(len(bytecode)))
+	if kl == nil {
+		return nil, self.ExceptionOccurred()
+	}
+	c = newClass(C.jclass(C.envNewGlobalRef(self.env, kl)))
+	if system {
+		if old, ok := self.classes[klass.AsPath()]; ok {
+			self.DeleteGlobalClassRef(old)
+		}
+		self.classes[klass.AsPath()] = newClass(C.jclass(C.envNewGlobalRef(self.env, kl)))
+	}
+	C.envDeleteLocalRef(self.env, kl)
+	return
+}
+
 // Wrapper around GetClass(types.NewName(...))
 func (self *Environment) GetClassStr(klass string) (c *Class, err error) {
 	class := types.NewName(klass)
@@ -268,69 +387,84 @@ func (self *Environment) _objMethod(obj *Object, name string, jt types.Typed, pa
 	if err != nil {
 		return
 	}
-	form, err := FormFor(self, jt, params...)
-	if err != nil {
-		return
-	}
-
-	cmethod := C.CString(name)
-	defer C.free(unsafe.Pointer(cmethod))
-	cform := C.CString(form)
-	defer C.free(unsafe.Pointer(cform))
+	return self.methodID(class, false, name, jt, params...)
+}
 
-	if debug {
-		log.Printf("_objMethod %V %V",  name, form)
-	}
-	m := C.envGetMethodID(self.env, class.class, cmethod, cform)
-	if m == nil {
-		err = self.ExceptionOccurred()
-	} else {
-		meth = &Method{m}
-	}
-	return
+func (self *Environment) _classMethod(class *Class, name string, jt types.Typed, params ...interface{}) (meth *Method, err error) {
+	return self.methodID(class, false, name, jt, params...)
+}
 
+func (self *Environment) _classStaticMethod(class *Class, name string, jt types.Typed, params ...interface{}) (meth *Method, err error) {
+	return self.methodID(class, true, name, jt, params...)
 }
 
-func (self *Environment) _classMethod(class *Class, name string, jt types.Typed, params ...interface{}) (meth *Method, err error) {
-	form, err := FormFor(self, jt, params...)
+/*
+	Resolves the method ID for name on class;  the signature reflected from
+	the parameters is tried first, and if the JVM has no such method, the
+	class's methods are searched for an overload reachable by (un)boxing
+	or reference widening (see matchMethod).  The exact lookup is muted, as
+	it is expected to miss;  the overload found is remembered (per class,
+	name and parameter types) so later calls skip the search.
+*/
+func (self *Environment) methodID(class *Class, static bool, name string, jt types.Typed, params ...interface{}) (meth *Method, err error) {
+	sig := types.MethodSignature{Return: jt}
+	sig.Params, err = ParameterTypes(self, params...)
 	if err != nil {
 		return
 	}
-	cmethod := C.CString(name)
-	defer C.free(unsafe.Pointer(cmethod))
-	cform := C.CString(form)
-	defer C.free(unsafe.Pointer(cform))
-	//cname, err := class.Name()
-	//if err != nil { return }
-	if debug {
-		log.Printf("_classMethod %V %V", name, form)
+	unmute := self.defMute()
+	meth, err = self.lookupMethod(class, static, name, sig)
+	unmute()
+	if err == nil {
+		return
 	}
-	m := C.envGetMethodID(self.env, class.class, cmethod, cform)
-	if m == nil {
-		err = self.ExceptionOccurred()
-	} else {
-		meth = &Method{m}
+	key, kerr := self.overloadKey(class, static, name, sig)
+	if msig, ok := self.overloads[key]; kerr == nil && ok {
+		if meth, merr := self.lookupMethod(class, static, name, msig); merr == nil {
+			return meth, nil
+		}
+	}
+	msig, merr := self.matchMethod(class, static, name, jt, params...)
+	if merr == nil {
+		meth, err = self.lookupMethod(class, static, name, msig)
+		if err == nil && kerr == nil {
+			self.overloads[key] = msig
+		}
 	}
 	return
 }
 
-func (self *Environment) _classStaticMethod(class *Class, name string, jt types.Typed, params ...interface{}) (meth *Method, err error) {
-	form, err := FormFor(self, jt, params...)
+// the key of the overload cache for a (missed) exact lookup of sig
+func (self *Environment) overloadKey(class *Class, static bool, name string, sig types.MethodSignature) (key string, err error) {
+	cname, err := class.GetName(self)
 	if err != nil {
 		return
 	}
+	key = cname.AsPath() + "." + name + sig.String()
+	if static {
+		key = "static " + key
+	}
+	return
+}
+
+func (self *Environment) lookupMethod(class *Class, static bool, name string, sig types.MethodSignature) (meth *Method, err error) {
 	cmethod := C.CString(name)
 	defer C.free(unsafe.Pointer(cmethod))
-	cform := C.CString(form)
+	cform := C.CString(sig.String())
 	defer C.free(unsafe.Pointer(cform))
-	//cname, err := class.Name()
-	//if err != nil { return }
-	//print("Looking for (static)", name, "\t", form, "\t in ", cname.AsPath(), "\n")
-	m := C.envGetStaticMethodID(self.env, class.class, cmethod, cform)
+	if debug {
+		log.Printf("lookupMethod %s %s (static: %v)", name, sig.String(), static)
+	}
+	var m C.jmethodID
+	if static {
+		m = C.envGetStaticMethodID(self.env, class.class, cmethod, cform)
+	} else {
+		m = C.envGetMethodID(self.env, class.class, cmethod, cform)
+	}
 	if m == nil {
 		err = self.ExceptionOccurred()
 	} else {
-		meth = &Method{m}
+		meth = &Method{m, sig}
 	}
 	return
 }
@@ -369,6 +503,24 @@ func (self *Environment) ExceptionOccurred() (ex *Exception) {
 	return
 }
 
+/*
+	Throws a new exception of class name (a Throwable with a String
+	constructor) in this thread;  it is pending until control returns to Java,
+	as from a Go native.
+*/
+func (self *Environment) ThrowNew(name types.Name, msg string) (err error) {
+	class, err := self.GetClass(name)
+	if err != nil {
+		return
+	}
+	cmsg := C.CString(msg)
+	defer C.free(unsafe.Pointer(cmsg))
+	if 0 != C.envThrowNew(self.env, class.class, cmsg) {
+		err = self.ExceptionOccurred()
+	}
+	return
+}
+
 // Returns true if an ExceptionOccurred in this thread
 // should produce a non-nil *Exception
 func (self *Environment) ExceptionCheck() bool {
@@ -395,6 +547,49 @@ func (self *Environment) DeleteLocalRef(o *Object) {
 	C.envDeleteLocalRef(self.env, o.object)
 }
 
+// true if a and b refer to the same Java object (or are both null)
+func (self *Environment) IsSameObject(a, b *Object) bool {
+	var ao, bo C.jobject
+	if a != nil {
+		ao = a.object
+	}
+	if b != nil {
+		bo = b.object
+	}
+	return asBool(C.envIsSameObject(self.env, ao, bo))
+}
+
+// true if obj is an instance of class c (or one of its subclasses or implementors);
+// a null obj is an instance of nothing.
+func (self *Environment) IsInstanceOf(obj *Object, c *Class) bool {
+	if obj == nil || obj.object == nil {
+		return false
+	}
+	return asBool(C.envIsInstanceOf(self.env, obj.object, c.class))
+}
+
+// true if a value of class b can be assigned to a variable of class a, as
+// a.isAssignableFrom(b) in Java (i.e., b is a, or extends or implements it).
+func (self *Environment) IsAssignableFrom(a, b *Class) bool {
+	return asBool(C.envIsAssignableFrom(self.env, b.class, a.class))
+}
+
+/*
+	Returns obj as a *CastObject of the named class, checking first that it
+	really is an instance (else ErrBadCast).  Passing the result as a parameter
+	selects overloads by the cast type rather than the object's own class.
+*/
+func (self *Environment) Cast(obj *Object, name types.Name) (cast *CastObject, err error) {
+	c, err := self.GetClass(name)
+	if err != nil {
+		return
+	}
+	if !self.IsInstanceOf(obj, c) {
+		return nil, ErrBadCast
+	}
+	return &CastObject{obj, name}, nil
+}
+
 // As gojvm is typically the /hosting/ context,
 // a global reference in gojvm is more of a 'dont bother GC'ing this,
 // I'm going to lose it somewhere in my stack',
@@ -403,50 +598,84 @@ func (self *Environment) NewGlobalRef(o *Object) *Object {
 	return newObject(C.envNewGlobalRef(self.env, o.object))
 }
 
-func (self *Environment) RegisterNative(className string, method string, sig types.MethodSignature, fptr interface{}) error {
-    class, err := self.GetClass(types.NewName(className))
-    if err != nil {
-        return err
-    }
-    cname := C.CString(method)
-    defer C.free(unsafe.Pointer(cname))
+// Release a global reference (from NewGlobalRef, NewInstance, ...) back to the JVM
+func (self *Environment) DeleteGlobalRef(o *Object) {
+	C.envDeleteGlobalRef(self.env, o.object)
+}
 
-    csig := C.CString(sig.String())
-    defer C.free(unsafe.Pointer(csig))
-    C.envRegisterNative(self.env, class.class, cname, csig, fptr.(unsafe.Pointer))
+// Syntactic sugar around DeleteGlobalRef(&Object{C.jobject(class.class)})
+func (self *Environment) DeleteGlobalClassRef(c *Class) {
+	C.envDeleteGlobalRef(self.env, c.class)
+}
 
-    return nil
+/*
+	Releases the global refs cached by other (which may belong to another
+	thread) through this environment, leaving other's cache empty.
+*/
+func (self *Environment) releaseCache(other *Environment) {
+	for _, c := range other.classes {
+		self.DeleteGlobalClassRef(c)
+	}
+	other.classes = map[string]*Class{}
+	if other._UTF8 != nil {
+		C.envDeleteGlobalRef(self.env, C.jobject(other._UTF8))
+		other._UTF8 = nil
+	}
 }
 
 /*
-func (self *Environment) UnregisterNatives(c *Class) (err error) {
-	if 0 != C.envUnregisterNatives(self.env, c.class) {
-		err = self.ExceptionOccurred()
+	Binds the native method 'method' (of signature sig) of className to fptr,
+	which is either a C function pointer (unsafe.Pointer), or a Go func of the
+	form accepted by CallbackDescriptor:
+
+		func(env *Environment, obj *Object, params...) [result]
+
+	where obj is the receiver (or the class, for static natives).  For a Go
+	func an empty sig uses the reflected signature;  otherwise the func must
+	agree with sig, taking objects as *Object (or string, for java/lang/String).
+
+	Go natives may be called from any Java thread;  env is only valid for the
+	duration of the call.  A panic is thrown to Java as a RuntimeException.
+*/
+func (self *Environment) RegisterNative(className string, method string, sig types.MethodSignature, fptr interface{}) error {
+	class, err := self.GetClass(types.NewName(className))
+	if err != nil {
+		return err
 	}
-	return
+	return self.registerNative(class, method, sig, fptr)
 }
 
-func (self *Environment) RegisterNative(c *Class, name string, fptr interface{}) (err error) {
-	//	env.RegisterNative(klass, "NativePing", func(E *environment.Environment, O *environment.Object)(Error){
-	//		nativePings += 1
-	//	})
+// RegisterNative, for a class GetClass may not find (as one defined in another loader)
+func (self *Environment) registerNative(class *Class, method string, sig types.MethodSignature, fptr interface{}) (err error) {
+	if ptr, ok := fptr.(unsafe.Pointer); ok {
+		return self.bindNative(class, method, sig, ptr)
+	}
+	return self.jvm.registerGoNative(self, class, method, sig, fptr)
+}
 
-	cname := C.CString(name)
+// binds the C function ptr as the native method of class
+func (self *Environment) bindNative(class *Class, method string, sig types.MethodSignature, ptr unsafe.Pointer) error {
+	cname := C.CString(method)
 	defer C.free(unsafe.Pointer(cname))
-	id, sig, err := self.jvm.addNative(self, fptr)
+
 	csig := C.CString(sig.String())
 	defer C.free(unsafe.Pointer(csig))
-	if err != nil {
-		return
+	if 0 != C.envRegisterNative(self.env, class.class, cname, csig, ptr) {
+		return self.ExceptionOccurred()
 	}
+	return nil
+}
 
-	if 0 != C.envRegisterNative(self.env, c.class, cname, csig, C.int(id)) {
+// Unbinds all natives registered for c (by RegisterNative or otherwise);
+// the slots of its Go natives are free for others afterwards.
+func (self *Environment) UnregisterNatives(c *Class) (err error) {
+	if 0 != C.envUnregisterNatives(self.env, c.class) {
 		err = self.ExceptionOccurred()
+	} else if self.jvm != nil {
+		self.jvm.releaseNatives(self, c)
 	}
 	return
 }
-*/
-
 
 /* CallObject methods */
 func asBool(jb C.jboolean) bool {
@@ -480,6 +709,23 @@ func (self *Environment) CallClassLong(obj *Class, static bool, name string, par
 	return self.callLong(obj, static, name, params...)
 }
 
+func (self *Environment) CallObjectByte(obj *Object, static bool, name string, params ...interface{}) (v int8, err error) {
+	return self.callByte(obj, static, name, params...)
+}
+
+func (self *Environment) CallClassByte(obj *Class, static bool, name string, params ...interface{}) (v int8, err error) {
+	return self.callByte(obj, static, name, params...)
+}
+
+// Java chars are returned as (UTF-16) uint16s;  rune(v) for the Go character
+func (self *Environment) CallObjectChar(obj *Object, static bool, name string, params ...interface{}) (v uint16, err error) {
+	return self.callChar(obj, static, name, params...)
+}
+
+func (self *Environment) CallClassChar(obj *Class, static bool, name string, params ...interface{}) (v uint16, err error) {
+	return self.callChar(obj, static, name, params...)
+}
+
 func (self *Environment) CallObjectShort(obj *Object, static bool, name string, params ...interface{}) (v int16, err error) {
 	return self.callShort(obj, static, name, params...)
 }
@@ -528,6 +774,22 @@ func (self *Environment) CallClassIntArray(obj *Class, static bool, name string,
 	return self.callIntArray(obj, static, name, params...)
 }
 
+func (self *Environment) CallObjectByteArray(obj *Object, static bool, name string, params ...interface{}) (v []int8, err error) {
+	return self.callByteArray(obj, static, name, params...)
+}
+
+func (self *Environment) CallClassByteArray(obj *Class, static bool, name string, params ...interface{}) (v []int8, err error) {
+	return self.callByteArray(obj, static, name, params...)
+}
+
+func (self *Environment) CallObjectCharArray(obj *Object, static bool, name string, params ...interface{}) (v []uint16, err error) {
+	return self.callCharArray(obj, static, name, params...)
+}
+
+func (self *Environment) CallClassCharArray(obj *Class, static bool, name string, params ...interface{}) (v []uint16, err error) {
+	return self.callCharArray(obj, static, name, params...)
+}
+
 func (self *Environment) CallObjectObj(obj *Object, static bool, name string, rtype types.Typed, params ...interface{}) (v *Object, err error) {
 	return self.callObj(obj, static, name, rtype, params...)
 }
@@ -560,6 +822,13 @@ func (self *Environment) callBool(z interface{}, static bool, name string, param
 		return
 	}
 	defer blowStack(self, localStack)
+	if meth.boxedReturn() {
+		var bv interface{}
+		if bv, err = self.callBoxed(jval, static, meth, args); err == nil {
+			b = bv.(bool)
+		}
+		return
+	}
 	var ji C.jboolean
 	if static {
 		ji = C.envCallStaticBoolMethodA(self.env, C.valObject(jval), meth.method, args.Ptr())
@@ -598,6 +867,13 @@ func (self *Environment) callInt(z interface{}, static bool, name string, params
 		return
 	}
 	defer blowStack(self, localStack)
+	if meth.boxedReturn() {
+		var bv interface{}
+		if bv, err = self.callBoxed(jval, static, meth, args); err == nil {
+			v = bv.(int)
+		}
+		return
+	}
 	var ji C.jint
 	if static {
 		ji = C.envCallStaticIntMethodA(self.env, C.valObject(jval), meth.method, args.Ptr())
@@ -617,6 +893,13 @@ func (self *Environment) callDouble(z interface{}, static bool, name string, par
 		return
 	}
 	defer blowStack(self, localStack)
+	if meth.boxedReturn() {
+		var bv interface{}
+		if bv, err = self.callBoxed(jval, static, meth, args); err == nil {
+			v = bv.(float64)
+		}
+		return
+	}
 	var ji C.jdouble
 	if static {
 		ji = C.envCallStaticDoubleMethodA(self.env, C.valObject(jval), meth.method, args.Ptr())
@@ -636,6 +919,13 @@ func (self *Environment) callFloat(z interface{}, static bool, name string, para
 		return
 	}
 	defer blowStack(self, localStack)
+	if meth.boxedReturn() {
+		var bv interface{}
+		if bv, err = self.callBoxed(jval, static, meth, args); err == nil {
+			v = bv.(float32)
+		}
+		return
+	}
 	var ji C.jfloat
 	if static {
 		ji = C.envCallStaticFloatMethodA(self.env, C.valObject(jval), meth.method, args.Ptr())
@@ -676,6 +966,13 @@ func (self *Environment) callLong(z interface{}, static bool, name string, param
 		return
 	}
 	defer blowStack(self, localStack)
+	if meth.boxedReturn() {
+		var bv interface{}
+		if bv, err = self.callBoxed(jval, static, meth, args); err == nil {
+			v = bv.(int64)
+		}
+		return
+	}
 	var oval C.jlong
 	if static {
 		oval = C.envCallStaticLongMethodA(self.env, C.valObject(jval), meth.method, args.Ptr())
@@ -697,6 +994,13 @@ func (self *Environment) callShort(z interface{}, static bool, name string, para
 		return
 	}
 	defer blowStack(self, localStack)
+	if meth.boxedReturn() {
+		var bv interface{}
+		if bv, err = self.callBoxed(jval, static, meth, args); err == nil {
+			v = bv.(int16)
+		}
+		return
+	}
 	var oval C.jshort
 	if static {
 		oval = C.envCallStaticShortMethodA(self.env, C.valObject(jval), meth.method, args.Ptr())
@@ -712,6 +1016,210 @@ func (self *Environment) callShort(z interface{}, static bool, name string, para
 	return
 }
 
+func (self *Environment) callByte(z interface{}, static bool, name string, params ...interface{}) (v int8, err error) {
+	jval, meth, args, localStack, err := self.getMethod(z, static, name, types.Basic(types.ByteKind), params...)
+	if err != nil {
+		return
+	}
+	defer blowStack(self, localStack)
+	if meth.boxedReturn() {
+		var bv interface{}
+		if bv, err = self.callBoxed(jval, static, meth, args); err == nil {
+			v = bv.(int8)
+		}
+		return
+	}
+	var oval C.jbyte
+	if static {
+		oval = C.envCallStaticByteMethodA(self.env, C.valObject(jval), meth.method, args.Ptr())
+	} else {
+		oval = C.envCallByteMethodA(self.env, C.valObject(jval), meth.method, args.Ptr())
+	}
+	if self.ExceptionCheck() {
+		err = self.ExceptionOccurred()
+	}
+	if err == nil {
+		v = int8(oval)
+	}
+	return
+}
+
+func (self *Environment) callChar(z interface{}, static bool, name string, params ...interface{}) (v uint16, err error) {
+	jval, meth, args, localStack, err := self.getMethod(z, static, name, types.Basic(types.CharKind), params...)
+	if err != nil {
+		return
+	}
+	defer blowStack(self, localStack)
+	if meth.boxedReturn() {
+		var bv interface{}
+		if bv, err = self.callBoxed(jval, static, meth, args); err == nil {
+			v = bv.(uint16)
+		}
+		return
+	}
+	var oval C.jchar
+	if static {
+		oval = C.envCallStaticCharMethodA(self.env, C.valObject(jval), meth.method, args.Ptr())
+	} else {
+		oval = C.envCallCharMethodA(self.env, C.valObject(jval), meth.method, args.Ptr())
+	}
+	if self.ExceptionCheck() {
+		err = self.ExceptionOccurred()
+	}
+	if err == nil {
+		v = uint16(oval)
+	}
+	return
+}
+
+// Invokes a method resolved to return a primitive wrapper (see Method.boxedReturn)
+// and returns the unboxed result.
+func (self *Environment) callBoxed(jval C.jvalue, static bool, meth *Method, args argList) (v interface{}, err error) {
+	var oval C.jobject
+	if static {
+		oval = C.envCallStaticObjectMethodA(self.env, C.valObject(jval), meth.method, args.Ptr())
+	} else {
+		oval = C.envCallObjectMethodA(self.env, C.valObject(jval), meth.method, args.Ptr())
+	}
+	if self.ExceptionCheck() {
+		err = self.ExceptionOccurred()
+	}
+	if err == nil && oval == nil {
+		err = ErrNullUnbox
+	}
+	if err == nil {
+		obj := newObject(oval)
+		defer self.DeleteLocalRef(obj)
+		v, err = self.Unbox(obj)
+	}
+	return
+}
+
+/*
+	Calls the named method, returning its result as a Go value of the kind
+	described by rtype (nil for void).  Primitive wrapper results are unboxed
+	(so an Integer comes back as an int), as are primitive returns requested
+	through their wrapper type;  any other object is returned as an *Object.
+*/
+func (self *Environment) Call(z interface{}, static bool, name string, rtype types.Typed, params ...interface{}) (v interface{}, err error) {
+	switch rtype.Kind() {
+	case types.VoidKind:
+		err = self.callVoid(z, static, name, params...)
+	case types.BoolKind:
+		v, err = self.callBool(z, static, name, params...)
+	case types.ByteKind:
+		v, err = self.callByte(z, static, name, params...)
+	case types.CharKind:
+		v, err = self.callChar(z, static, name, params...)
+	case types.ShortKind:
+		v, err = self.callShort(z, static, name, params...)
+	case types.IntKind:
+		v, err = self.callInt(z, static, name, params...)
+	case types.LongKind:
+		v, err = self.callLong(z, static, name, params...)
+	case types.FloatKind:
+		v, err = self.callFloat(z, static, name, params...)
+	case types.DoubleKind:
+		v, err = self.callDouble(z, static, name, params...)
+	default:
+		if c, ok := rtype.(types.Class); ok {
+			if k, boxed := types.UnboxedKind(c.Klass); boxed {
+				v, err = self.Call(z, static, name, types.Basic(k), params...)
+				if err == ErrNullUnbox {
+					v, err = nil, nil
+				}
+				return
+			}
+		}
+		var obj *Object
+		obj, err = self.callObj(z, static, name, rtype, params...)
+		if err == nil {
+			v, err = self.goValue(obj)
+		}
+	}
+	return
+}
+
+// Returns obj as the generic call API presents it:  primitive wrappers
+// are unboxed (and their reference released), anything else is returned as is.
+func (self *Environment) goValue(obj *Object) (v interface{}, err error) {
+	if obj.object == nil {
+		return obj, nil
+	}
+	oname, err := obj.Name(self)
+	if err != nil {
+		return
+	}
+	if _, boxed := types.UnboxedKind(oname); boxed {
+		defer self.DeleteLocalRef(obj)
+		return self.Unbox(obj)
+	}
+	return obj, nil
+}
+
+/*
+	Calls the implementation of the named method found in class (normally a
+	superclass of obj's own), bypassing virtual dispatch;  this is how an
+	overriding (e.g., Go native) method reaches its super implementation.
+
+	The result is returned as by Call.
+*/
+func (self *Environment) CallNonvirtual(obj *Object, class *Class, name string, rtype types.Typed, params ...interface{}) (v interface{}, err error) {
+	if c, ok := rtype.(types.Class); ok {
+		if k, boxed := types.UnboxedKind(c.Klass); boxed {
+			v, err = self.CallNonvirtual(obj, class, name, types.Basic(k), params...)
+			if err == ErrNullUnbox {
+				v, err = nil, nil
+			}
+			return
+		}
+	}
+	meth, err := self.methodID(class, false, name, rtype, params...)
+	if err != nil {
+		return
+	}
+	args, localStack, err := newMethodArgList(self, meth, params...)
+	if err != nil {
+		return
+	}
+	defer blowStack(self, localStack)
+	o, c, m, a := obj.object, class.class, meth.method, args.Ptr()
+	var oval C.jobject
+	switch meth.sig.Return.Kind() {
+	case types.VoidKind:
+		C.envCallNonvirtualVoidMethodA(self.env, o, c, m, a)
+	case types.BoolKind:
+		v = asBool(C.envCallNonvirtualBoolMethodA(self.env, o, c, m, a))
+	case types.ByteKind:
+		v = int8(C.envCallNonvirtualByteMethodA(self.env, o, c, m, a))
+	case types.CharKind:
+		v = uint16(C.envCallNonvirtualCharMethodA(self.env, o, c, m, a))
+	case types.ShortKind:
+		v = int16(C.envCallNonvirtualShortMethodA(self.env, o, c, m, a))
+	case types.IntKind:
+		v = int(C.envCallNonvirtualIntMethodA(self.env, o, c, m, a))
+	case types.LongKind:
+		v = int64(C.envCallNonvirtualLongMethodA(self.env, o, c, m, a))
+	case types.FloatKind:
+		v = float32(C.envCallNonvirtualFloatMethodA(self.env, o, c, m, a))
+	case types.DoubleKind:
+		v = float64(C.envCallNonvirtualDoubleMethodA(self.env, o, c, m, a))
+	default:
+		oval = C.envCallNonvirtualObjectMethodA(self.env, o, c, m, a)
+	}
+	if self.ExceptionCheck() {
+		return nil, self.ExceptionOccurred()
+	}
+	if k := meth.sig.Return.Kind(); k == types.ClassKind || k == types.ArrayKind {
+		if oval == nil && rtype.Kind() != k {
+			// a primitive was asked for, but the (boxed) result was null
+			return nil, ErrNullUnbox
+		}
+		v, err = self.goValue(newObject(oval))
+	}
+	return
+}
+
 func (self *Environment) callLongArray(z interface{}, static bool, name string, params ...interface{}) (v []int64, err error) {
 	jval, meth, args, localStack, err := self.getMethod(z, static, name, types.Array{types.Basic(types.LongKind)}, params...)
 	if err != nil {
@@ -754,6 +1262,54 @@ func (self *Environment) callIntArray(z interface{}, static bool, name string, p
 	return
 }
 
+func (self *Environment) callByteArray(z interface{}, static bool, name string, params ...interface{}) (v []int8, err error) {
+	jval, meth, args, localStack, err := self.getMethod(z, static, name, types.Array{types.Basic(types.ByteKind)}, params...)
+	if err != nil {
+		return
+	}
+	defer blowStack(self, localStack)
+	var oval C.jobject
+	if static {
+		oval = C.envCallStaticObjectMethodA(self.env, C.valObject(jval), meth.method, args.Ptr())
+	} else {
+		oval = C.envCallObjectMethodA(self.env, C.valObject(jval), meth.method, args.Ptr())
+	}
+	if oval == nil {
+		// a null array (nil slice), unless the call threw
+		if ex := self.ExceptionOccurred(); ex != nil {
+			err = ex
+		}
+		return
+	}
+	defer C.envDeleteLocalRef(self.env, oval)
+	v = self.ToInt8Array(newObject(oval))
+	return
+}
+
+func (self *Environment) callCharArray(z interface{}, static bool, name string, params ...interface{}) (v []uint16, err error) {
+	jval, meth, args, localStack, err := self.getMethod(z, static, name, types.Array{types.Basic(types.CharKind)}, params...)
+	if err != nil {
+		return
+	}
+	defer blowStack(self, localStack)
+	var oval C.jobject
+	if static {
+		oval = C.envCallStaticObjectMethodA(self.env, C.valObject(jval), meth.method, args.Ptr())
+	} else {
+		oval = C.envCallObjectMethodA(self.env, C.valObject(jval), meth.method, args.Ptr())
+	}
+	if oval == nil {
+		// a null array (nil slice), unless the call threw
+		if ex := self.ExceptionOccurred(); ex != nil {
+			err = ex
+		}
+		return
+	}
+	defer C.envDeleteLocalRef(self.env, oval)
+	v = self.ToUint16Array(newObject(oval))
+	return
+}
+
 func (self *Environment) ToString(strobj *Object) (str string, isNull bool, err error) {
 	if strobj.object == nil {
 		isNull = true
@@ -796,6 +1352,26 @@ func (self *Environment) ToIntArray(arrayObj *Object) (array []int) {
 	return
 }
 
+// Copies out the contents of a Java byte[]
+func (self *Environment) ToInt8Array(arrayObj *Object) (array []int8) {
+	alen := C.envGetArrayLength(self.env, arrayObj.object)
+	array = make([]int8, int(alen))
+	if alen > 0 {
+		C.envGetByteArrayRegion(self.env, arrayObj.object, 0, alen, unsafe.Pointer(&array[0]))
+	}
+	return
+}
+
+// Copies out the contents of a Java char[] (as UTF-16 code units)
+func (self *Environment) ToUint16Array(arrayObj *Object) (array []uint16) {
+	alen := C.envGetArrayLength(self.env, arrayObj.object)
+	array = make([]uint16, int(alen))
+	if alen > 0 {
+		C.envGetCharArrayRegion(self.env, arrayObj.object, 0, alen, unsafe.Pointer(&array[0]))
+	}
+	return
+}
+
 func (self *Environment) ToObjectArray(arrayObj *Object) []*Object {
     var glen int
     if arrayObj.object == nil {
@@ -820,6 +1396,14 @@ func (self *Environment) GetClassBooleanField(obj *Class, static bool, name stri
 	return self.getBoolField(obj, static, name)
 }
 
+func (self *Environment) GetClassByteField(obj *Class, static bool, name string) (v int8, err error) {
+	return self.getByteField(obj, static, name)
+}
+
+func (self *Environment) GetClassCharField(obj *Class, static bool, name string) (v uint16, err error) {
+	return self.getCharField(obj, static, name)
+}
+
 func (self *Environment) GetClassShortField(obj *Class, static bool, name string) (v int16, err error) {
 	return self.getShortField(obj, static, name)
 }
@@ -849,6 +1433,14 @@ func (self *Environment) GetObjectBooleanField(obj *Object, static bool, name st
     return self.getBoolField(obj, static, name)
 }
 
+func (self *Environment) GetObjectByteField(obj *Object, static bool, name string) (v int8, err error) {
+    return self.getByteField(obj, static, name)
+}
+
+func (self *Environment) GetObjectCharField(obj *Object, static bool, name string) (v uint16, err error) {
+    return self.getCharField(obj, static, name)
+}
+
 func (self *Environment) GetObjectShortField(obj *Object, static bool, name string) (v int16, err error) {
     return self.getShortField(obj, static, name)
 }
@@ -873,6 +1465,14 @@ func (self *Environment) GetObjecIntArrayField(obj *Object, static bool, name st
     return self.getIntArrayField(obj, static, name)
 }
 
+func (self *Environment) GetObjectByteArrayField(obj *Object, static bool, name string) (v []int8, err error) {
+    return self.getByteArrayField(obj, static, name)
+}
+
+func (self *Environment) GetObjectCharArrayField(obj *Object, static bool, name string) (v []uint16, err error) {
+    return self.getCharArrayField(obj, static, name)
+}
+
 type Field struct {
 	field C.jfieldID
 }
@@ -883,12 +1483,10 @@ func (self *Environment) getClassField(c *Class, static bool, mname string, rTyp
 	cform := C.CString(rType.TypeString())
 	defer C.free(unsafe.Pointer(cform))
 
-	var m C.jfieldID 
 	if !static {
-		//todo
-	} else {
-		m = C.envGetStaticFieldID(self.env, c.class, cmethod, cform)
+		return nil, ErrInstanceField
 	}
+	m := C.envGetStaticFieldID(self.env, c.class, cmethod, cform)
 	if m == nil {
 		err = self.ExceptionOccurred()
 		return nil, err
@@ -988,6 +1586,46 @@ func (self *Environment) getBoolField(z interface{}, static bool, name string) (
 	return
 }
 
+func (self *Environment) getByteField(z interface{}, static bool, name string) (v int8, err error) {
+	jval, field, err := self.getField(z, static, name, types.Basic(types.ByteKind))
+	if err != nil {
+		return
+	}
+	var oval C.jbyte
+	if static {
+		oval = C.envGetStaticByteField(self.env, C.valObject(jval), field.field);
+	} else {
+		oval = C.envGetByteField(self.env, C.valObject(jval), field.field);
+	}
+	if self.ExceptionCheck() {
+		err = self.ExceptionOccurred()
+	}
+	if err == nil {
+		v = int8(oval)
+	}
+	return
+}
+
+func (self *Environment) getCharField(z interface{}, static bool, name string) (v uint16, err error) {
+	jval, field, err := self.getField(z, static, name, types.Basic(types.CharKind))
+	if err != nil {
+		return
+	}
+	var oval C.jchar
+	if static {
+		oval = C.envGetStaticCharField(self.env, C.valObject(jval), field.field);
+	} else {
+		oval = C.envGetCharField(self.env, C.valObject(jval), field.field);
+	}
+	if self.ExceptionCheck() {
+		err = self.ExceptionOccurred()
+	}
+	if err == nil {
+		v = uint16(oval)
+	}
+	return
+}
+
 func (self *Environment) getShortField(z interface{}, static bool, name string) (v int16, err error) {
 	jval, field, err := self.getField(z, static, name, types.Basic(types.ShortKind))
 	if err != nil {
@@ -1029,7 +1667,7 @@ func (self *Environment) getIntField(z interface{}, static bool, name string) (v
 }
 
 func (self *Environment) getLongField(z interface{}, static bool, name string) (v int64, err error) {
-	jval, field, err := self.getField(z, static, name, types.Basic(types.IntKind))
+	jval, field, err := self.getField(z, static, name, types.Basic(types.LongKind))
 	if err != nil {
 		return
 	}
@@ -1049,7 +1687,7 @@ func (self *Environment) getLongField(z interface{}, static bool, name string) (
 }
 
 func (self *Environment) getFloatField(z interface{}, static bool, name string) (v float32, err error) {
-	jval, field, err := self.getField(z, static, name, types.Basic(types.IntKind))
+	jval, field, err := self.getField(z, static, name, types.Basic(types.FloatKind))
 	if err != nil {
 		return
 	}
@@ -1069,7 +1707,7 @@ func (self *Environment) getFloatField(z interface{}, static bool, name string)
 }
 
 func (self *Environment) getDobuleField(z interface{}, static bool, name string) (v float64, err error) {
-	jval, field, err := self.getField(z, static, name, types.Basic(types.IntKind))
+	jval, field, err := self.getField(z, static, name, types.Basic(types.DoubleKind))
 	if err != nil {
 		return
 	}
@@ -1109,6 +1747,50 @@ func (self *Environment) getIntArrayField(z interface{}, static bool, name strin
     return
 }
 
+func (self *Environment) getByteArrayField(z interface{}, static bool, name string) (v []int8, err error) {
+    jval, field, err := self.getField(z, static, name, types.Array{types.Basic(types.ByteKind)})
+    if err != nil {
+        return
+    }
+    var oval C.jobject
+    if static {
+        oval = C.envGetStaticObjectField(self.env, C.valObject(jval), field.field)
+    } else {
+        oval = C.envGetObjectField(self.env, C.valObject(jval), field.field)
+    }
+    if oval == nil {
+        if ex := self.ExceptionOccurred(); ex != nil {
+            err = ex
+        }
+        return
+    }
+    defer C.envDeleteLocalRef(self.env, oval)
+    v = self.ToInt8Array(newObject(oval))
+    return
+}
+
+func (self *Environment) getCharArrayField(z interface{}, static bool, name string) (v []uint16, err error) {
+    jval, field, err := self.getField(z, static, name, types.Array{types.Basic(types.CharKind)})
+    if err != nil {
+        return
+    }
+    var oval C.jobject
+    if static {
+        oval = C.envGetStaticObjectField(self.env, C.valObject(jval), field.field)
+    } else {
+        oval = C.envGetObjectField(self.env, C.valObject(jval), field.field)
+    }
+    if oval == nil {
+        if ex := self.ExceptionOccurred(); ex != nil {
+            err = ex
+        }
+        return
+    }
+    defer C.envDeleteLocalRef(self.env, oval)
+    v = self.ToUint16Array(newObject(oval))
+    return
+}
+
 /* ==== */
 
 func (self *Environment) setObjField(z interface{}, static bool, name string, rval types.Typed, val *Object) (err error) {
@@ -1116,10 +1798,14 @@ func (self *Environment) setObjField(z interface{}, static bool, name string, rv
     if err != nil {
         return
     }
+    var oval C.jobject // a nil *Object sets the field to null
+    if val != nil {
+        oval = val.object
+    }
     if static {
-        C.envSetStaticObjectField(self.env, C.valObject(jval), field.field, val.object);
+        C.envSetStaticObjectField(self.env, C.valObject(jval), field.field, oval);
     } else {
-        C.envSetObjectField(self.env, C.valObject(jval), field.field, val.object);
+        C.envSetObjectField(self.env, C.valObject(jval), field.field, oval);
     }
     if self.ExceptionCheck() {
         err = self.ExceptionOccurred()
@@ -1147,6 +1833,38 @@ func (self *Environment) setBoolField(z interface{}, static bool, name string, g
     return
 }
 
+func (self *Environment) setByteField(z interface{}, static bool, name string, val int8) (err error) {
+    jval, field, err := self.getField(z, static, name, types.Basic(types.ByteKind))
+    if err != nil {
+        return
+    }
+    if static {
+        C.envSetStaticByteField(self.env, C.valObject(jval), field.field, C.jbyte(val));
+    } else {
+        C.envSetByteField(self.env, C.valObject(jval), field.field, C.jbyte(val));
+    }
+    if self.ExceptionCheck() {
+        err = self.ExceptionOccurred()
+    }
+    return
+}
+
+func (self *Environment) setCharField(z interface{}, static bool, name string, val uint16) (err error) {
+    jval, field, err := self.getField(z, static, name, types.Basic(types.CharKind))
+    if err != nil {
+        return
+    }
+    if static {
+        C.envSetStaticCharField(self.env, C.valObject(jval), field.field, C.jchar(val));
+    } else {
+        C.envSetCharField(self.env, C.valObject(jval), field.field, C.jchar(val));
+    }
+    if self.ExceptionCheck() {
+        err = self.ExceptionOccurred()
+    }
+    return
+}
+
 func (self *Environment) setShortField(z interface{}, static bool, name string, val int16) (err error) {
     jval, field, err := self.getField(z, static, name, types.Basic(types.ShortKind))
     if err != nil {
@@ -1180,7 +1898,7 @@ func (self *Environment) setIntField(z interface{}, static bool, name string, va
 }
 
 func (self *Environment) setLongField(z interface{}, static bool, name string, val int64) (err error) {
-    jval, field, err := self.getField(z, static, name, types.Basic(types.IntKind))
+    jval, field, err := self.getField(z, static, name, types.Basic(types.LongKind))
     if err != nil {
         return
     }
@@ -1196,7 +1914,7 @@ func (self *Environment) setLongField(z interface{}, static bool, name string, v
 }
 
 func (self *Environment) setFloatField(z interface{}, static bool, name string, val float32) (err error) {
-    jval, field, err := self.getField(z, static, name, types.Basic(types.IntKind))
+    jval, field, err := self.getField(z, static, name, types.Basic(types.FloatKind))
     if err != nil {
         return
     }
@@ -1212,7 +1930,7 @@ func (self *Environment) setFloatField(z interface{}, static bool, name string,
 }
 
 func (self *Environment) setDoubleField(z interface{}, static bool, name string, val float64) (err error) {
-    jval, field, err := self.getField(z, static, name, types.Basic(types.IntKind))
+    jval, field, err := self.getField(z, static, name, types.Basic(types.DoubleKind))
     if err != nil {
         return
     }
@@ -1226,3 +1944,24 @@ func (self *Environment) setDoubleField(z interface{}, static bool, name string,
     }
     return
 }
+
+/*
+	Sets an array-typed field from a Go slice (or *ObjectArray);  the field's
+	type is taken from TypeOf(val), and the Java array is built as newArgList
+	would build it for a parameter.
+*/
+func (self *Environment) setArrayField(z interface{}, static bool, name string, val interface{}) (err error) {
+    rval, err := TypeOf(self, val)
+    if err == nil && rval.Kind() != types.ArrayKind {
+        err = errors.New("setArrayField: not an array type " + rval.TypeString())
+    }
+    if err != nil {
+        return
+    }
+    alp, localStack, err := newArgList(self, val)
+    if err != nil {
+        return
+    }
+    defer blowStack(self, localStack)
+    return self.setObjField(z, static, name, rval, newObject(C.valObject(alp[0])))
+}
@@ -12,6 +12,23 @@ type Error struct {
 var ErrUnimplemented = Error{-400, "Unimplemented functionality"}
 var ErrUnknownClass = Error{-403, "Unknown class"}
 var ErrUnknownMethod = Error{-404, "Unknown method"}
+var ErrNotBoxed = Error{-405, "Not a primitive or primitive wrapper"}
+var ErrNullUnbox = Error{-406, "Cannot unbox a null reference"}
+var ErrInstanceField = Error{-407, "Instance fields must be accessed through an Object"}
+var ErrArgCount = Error{-408, "Wrong number of arguments for the signature"}
+var ErrWrongKind = Error{-409, "Value does not match the declared Java type"}
+var ErrNullReceiver = Error{-410, "Instance member accessed through a null reference"}
+var ErrBadCast = Error{-411, "Object is not an instance of the class"}
+var ErrNoLibJVM = Error{-412, "Couldn't find libjvm;  set JvmConfig.JavaHome or $JAVA_HOME"}
+var ErrNoJVM = Error{-413, "No JVM is running in this process"}
+var ErrNativeSlots = Error{-414, "All Go native slots are in use"}
+var ErrNativeArgs = Error{-415, "Too many parameters for a Go native"}
+var ErrNativeABI = Error{-416, "Go natives are unsupported on this platform"}
+var ErrDestroyFailed = Error{-417, "DestroyJavaVM failed"}
+var ErrNoImplementation = Error{-418, "The Go value has no methods of the interface"}
+var ErrBadLambda = Error{-419, "The Go func doesn't fit the functional interface"}
+var ErrNotSubclassable = Error{-420, "The class is final, or an interface"}
+var ErrNoOverride = Error{-421, "No overridable method of the name matches the Go func"}
 
 func (self Error) Error() string {
 	return fmt.Sprintf("(%d) %q", self.Code, self.Message)
@@ -3,9 +3,6 @@ package gojvm
 //#include "helpers.h"
 import "C"
 import (
-	"errors"
-	"github.com/timob/gojvm/types"
-	"reflect"
 	"sync"
 	"unsafe"
 )
@@ -53,124 +50,69 @@ func (self *envPtrMap) Add(env *Environment) {
 	self.envs[uintptr(unsafe.Pointer(env.env))] = env
 }
 
-//export goCallbackNArgs
-func goCallbackNArgs(envp, obj uintptr, fId int) int {
-	env := AllEnvs.Find(envp)
-	if env == nil {
-		return -1
-	}
-	if env.jvm == nil {
-		return -1
-	}
-	var cd callbackDescriptor
-	var ok bool
-	if cd, ok = env.jvm.registered[fId]; !ok {
-		return -1
-	}
-	return len(cd.Signature.Params)
+func (self *vmPtrMap) Remove(vm *JVM) {
+	self.maplock.Lock()
+	defer self.maplock.Unlock()
+	delete(self.jvms, uintptr(unsafe.Pointer(vm.jvm)))
 }
 
-// C callbacks actually start in the 'generifiedX' calls (see the .c files)
-// Next, goCallbackNArgs is used to determine the number of variadic paramers to expect (
-// java doesn't inform us of this, and we can't force any of the callback parameters.
-//
-// Finally, goCallback looks up the 'fId' - our internal function reference ID (the X in generified),
-// un(re) marshalls all the parameters appropriately, calls our function, and returns
-// any underlying value back to generified who will return it to the JVM.  
-// The initial jbool indicates success, and any failure should check for exceptions.
-//
-//export goCallback
-func goCallback(envp, obj uintptr, fId int, nargs int, argp uintptr) (ok C.jboolean, val interface{}) {
-	args := C.ArgListPtr(unsafe.Pointer(argp))
-	env := AllEnvs.Find(envp)
-	if env == nil {
-		panic("Got a nil environment")
-	}
-	if env.jvm == nil {
-		panic("Environment pointer has no JVM")
-	}
-	var cd callbackDescriptor
-	var _ok bool
-	if cd, _ok = env.jvm.registered[fId]; !_ok {
-		print("Unknown callbackId: \t", fId, "\n")
-		return
-	}
-	// TODO: pack argp somehow...
-	if nargs != len(cd.Signature.Params) {
-		panic("callback/signature length mismatch")
-	}
-	inCall := []reflect.Value{reflect.ValueOf(env), reflect.ValueOf(newObject(C.jobject(unsafe.Pointer(obj))))}
-	var err error
-	for i := 0; i < nargs; i++ {
-		switch cd.Signature.Params[i].Kind() {
-		case types.BoolKind:
-			inCall = append(inCall, reflect.ValueOf(bool(0 != C.valBool(C.getArg(args, C.int(i))))))
-		case types.LongKind:
-			inCall = append(inCall, reflect.ValueOf(int64(C.valLong(C.getArg(args, C.int(i))))))
-		case types.IntKind:
-			inCall = append(inCall, reflect.ValueOf(int(C.valInt(C.getArg(args, C.int(i))))))
-		case types.ShortKind:
-			inCall = append(inCall, reflect.ValueOf(int16(C.valShort(C.getArg(args, C.int(i))))))
-		case types.FloatKind:
-			inCall = append(inCall, reflect.ValueOf(float32(C.valFloat(C.getArg(args, C.int(i))))))
-		case types.DoubleKind:
-			inCall = append(inCall, reflect.ValueOf(float64(C.valDouble(C.getArg(args, C.int(i))))))
-		case types.ClassKind:
-			inCall = append(inCall, reflect.ValueOf(newObject(C.valObject(C.getArg(args, C.int(i))))))
-		default:
-			err = errors.New("Couldn't reflect kind " + cd.Signature.Params[i].Kind().TypeString())
-		}
-		if err != nil {
-			break
+func (self *envPtrMap) Remove(env *Environment) {
+	self.maplock.Lock()
+	defer self.maplock.Unlock()
+	delete(self.envs, uintptr(unsafe.Pointer(env.env)))
+}
+
+// the environments (of any thread) belonging to vm
+func (self *envPtrMap) owned(vm *JVM) (envs []*Environment) {
+	self.maplock.RLock()
+	defer self.maplock.RUnlock()
+	for _, env := range self.envs {
+		if env.jvm == vm {
+			envs = append(envs, env)
 		}
 	}
-	if err != nil {
-		return
+	return
+}
+
+/*
+	Returns the JVM for vm, registering a new one in AllVMs if it is not
+	already known (as for a VM started by Java, rather than NewJVM).
+*/
+func (self *vmPtrMap) adopt(vm *C.JavaVM) *JVM {
+	self.maplock.Lock()
+	defer self.maplock.Unlock()
+	ptr := uintptr(unsafe.Pointer(vm))
+	if jvm, ok := self.jvms[ptr]; ok {
+		return jvm
 	}
-	outCall := reflect.ValueOf(cd.F).Call(inCall)
-	switch cd.Signature.Return.Kind() {
-	case types.VoidKind:
-		return 1, nil
+	jvm := newJVM(vm)
+	self.jvms[ptr] = jvm
+	return jvm
+}
+
+/*
+	Returns the Environment for a JNIEnv pointer handed to us by Java (in a
+	native call, or JNI_OnLoad);  threads Java started are unknown to AllEnvs,
+	so their environment (and if need be, JVM) is wrapped and registered for
+	the duration of the call only:  added is then true, and the caller must
+	forgetEnv it before returning to Java, as the thread may end unseen.
+*/
+func envFor(envp *C.JNIEnv) (env *Environment, added bool, err error) {
+	if env = AllEnvs.Find(uintptr(unsafe.Pointer(envp))); env != nil {
+		return
 	}
-	switch cd.Signature.Return.Kind() {
-	case types.BoolKind:
-		if outCall[0].Interface().(bool) {
-			return 1, C.jboolean(C.JNI_TRUE)
-		} else {
-			return 1, C.jboolean(C.JNI_FALSE)
-		}
-	case types.ByteKind:
-		return 1, C.jbyte(outCall[0].Interface().(byte))
-	case types.CharKind:
-		return 1, C.jchar(outCall[0].Interface().(int))
-	case types.IntKind:
-		return 1, C.jint(outCall[0].Interface().(int))
-	case types.ShortKind:
-		return 1, C.jshort(outCall[0].Interface().(int16))
-	case types.LongKind:
-		return 1, C.jint(outCall[0].Interface().(int64))
-	case types.FloatKind:
-		return 1, C.jfloat(outCall[0].Interface().(float32))
-	case types.DoubleKind:
-		return 1, C.jdouble(outCall[0].Interface().(float64))
-	case types.ClassKind:
-		klass := cd.Signature.Return.(types.Class).Klass
-		if klass.Cmp(types.JavaLangString) == 0 {
-			var obj *Object
-			str := outCall[0].Interface().(string)
-			obj, err = env.NewStringObject(str)
-			if err == nil {
-				return 1, C.jstring(obj.object)
-			} // else, exception occurred
-			// not needed as callbacks will reap their own refs.
-			// env.DeleteLocalRef(obj)
-			print("String Error\t", err.Error())
-			return 0, nil
-		}
-		return 1, C.jobject(outCall[0].Interface().(*Object).object)
-	default:
-		panic("array return type not yet supported")
+	var vm *C.JavaVM
+	if C.envGetJavaVM(envp, &vm) != C.JNI_OK || vm == nil {
+		return nil, false, ErrNoJVM
 	}
+	env = NewEnvironment(AllVMs.adopt(vm))
+	env.env = envp
+	AllEnvs.Add(env)
+	return env, true, nil
+}
 
-	panic("not reached")
+// releases the cached refs of an Environment envFor added, and forgets it
+func forgetEnv(env *Environment) {
+	env.releaseCache(env)
+	AllEnvs.Remove(env)
 }
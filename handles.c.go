@@ -0,0 +1,343 @@
+package gojvm
+
+//#include "helpers.h"
+import "C"
+import (
+	"github.com/timob/gojvm/types"
+	"unsafe"
+)
+
+/*
+	A method resolved once (see Class.Method) for repeated calls;  invoking
+	it skips the name lookup and the per-call signature reflection of the
+	Call* API, and parameters are converted to the declared types.
+
+	The handle holds a global ref of its class, so it stays valid across
+	threads and Environments (and the detaching of the thread it was resolved
+	on) until Release.
+*/
+type MethodHandle struct {
+	class  *Class
+	method *Method
+	Name   string
+	Static bool
+}
+
+/*
+	Resolves the named method of the class with exactly the signature sig;  an
+	instance method is looked for first, then a static one.  Constructors are
+	resolved with the name "<init>" (and a void return), and called with New.
+*/
+func (self *Class) Method(env *Environment, name string, sig types.MethodSignature) (h *MethodHandle, err error) {
+	if sig.Return == nil {
+		sig.Return = types.Basic(types.VoidKind)
+	}
+	static := false
+	var meth *Method
+	if name == "<init>" {
+		meth, err = env.lookupMethod(self, false, name, sig)
+	} else {
+		unmute := env.defMute()
+		meth, err = env.lookupMethod(self, false, name, sig)
+		unmute()
+		if err != nil {
+			static = true
+			meth, err = env.lookupMethod(self, true, name, sig)
+		}
+	}
+	if err == nil {
+		h = &MethodHandle{env.globalClass(self), meth, name, static}
+	}
+	return
+}
+
+// a new global ref of class, for a handle to hold
+func (self *Environment) globalClass(class *Class) *Class {
+	return newClass(C.jclass(C.envNewGlobalRef(self.env, C.jobject(class.class))))
+}
+
+// the (declared) signature of the method
+func (self *MethodHandle) Signature() types.MethodSignature { return self.method.sig }
+
+// Releases the handle's class ref;  the handle may not be used afterwards.
+func (self *MethodHandle) Release(env *Environment) {
+	if self.class != nil {
+		env.DeleteGlobalClassRef(self.class)
+		self.class = nil
+	}
+}
+
+// the JNI type character of t ('V', 'Z', 'I', 'L', '[' ...), as the kind-dispatching helpers take it
+func kindChar(t types.Typed) C.char {
+	return C.char(t.TypeString()[0])
+}
+
+func (self *MethodHandle) invoke(env *Environment, obj *Object, params []interface{}) (jv C.jvalue, err error) {
+	subject := C.jobject(self.class.class)
+	isStatic := C.jboolean(C.JNI_TRUE)
+	if !self.Static {
+		if obj == nil || obj.object == nil {
+			return jv, ErrNullReceiver
+		}
+		subject, isStatic = obj.object, C.JNI_FALSE
+	}
+	args, localStack, err := newTypedArgList(env, self.method.sig.Params, params...)
+	if err != nil {
+		return
+	}
+	defer blowStack(env, localStack)
+	jv = C.envCallMethodKindA(env.env, subject, self.method.method, kindChar(self.method.sig.Return), isStatic, args.Ptr())
+	if env.ExceptionCheck() {
+		err = env.ExceptionOccurred()
+	}
+	return
+}
+
+// invokes the method after checking its declared return is of kind k
+func (self *MethodHandle) invokeKind(env *Environment, k types.Kind, obj *Object, params []interface{}) (jv C.jvalue, err error) {
+	if self.method.sig.Return.Kind() != k {
+		return jv, ErrWrongKind
+	}
+	return self.invoke(env, obj, params)
+}
+
+/*
+	Invokes the method on obj (nil for static methods), returning the result
+	as Call does:  primitives as their Go types, declared wrapper returns
+	unboxed (nil for null), and other objects as a (local ref) *Object.
+*/
+func (self *MethodHandle) Invoke(env *Environment, obj *Object, params ...interface{}) (v interface{}, err error) {
+	jv, err := self.invoke(env, obj, params)
+	if err != nil {
+		return
+	}
+	return env.jvalueGo(self.method.sig.Return, jv)
+}
+
+func (self *MethodHandle) InvokeVoid(env *Environment, obj *Object, params ...interface{}) (err error) {
+	_, err = self.invokeKind(env, types.VoidKind, obj, params)
+	return
+}
+
+func (self *MethodHandle) InvokeBool(env *Environment, obj *Object, params ...interface{}) (v bool, err error) {
+	jv, err := self.invokeKind(env, types.BoolKind, obj, params)
+	return asBool(C.valBool(jv)), err
+}
+
+func (self *MethodHandle) InvokeByte(env *Environment, obj *Object, params ...interface{}) (v int8, err error) {
+	jv, err := self.invokeKind(env, types.ByteKind, obj, params)
+	return int8(C.valByte(jv)), err
+}
+
+func (self *MethodHandle) InvokeChar(env *Environment, obj *Object, params ...interface{}) (v uint16, err error) {
+	jv, err := self.invokeKind(env, types.CharKind, obj, params)
+	return uint16(C.valChar(jv)), err
+}
+
+func (self *MethodHandle) InvokeShort(env *Environment, obj *Object, params ...interface{}) (v int16, err error) {
+	jv, err := self.invokeKind(env, types.ShortKind, obj, params)
+	return int16(C.valShort(jv)), err
+}
+
+func (self *MethodHandle) InvokeInt(env *Environment, obj *Object, params ...interface{}) (v int, err error) {
+	jv, err := self.invokeKind(env, types.IntKind, obj, params)
+	return int(C.valInt(jv)), err
+}
+
+func (self *MethodHandle) InvokeLong(env *Environment, obj *Object, params ...interface{}) (v int64, err error) {
+	jv, err := self.invokeKind(env, types.LongKind, obj, params)
+	return int64(C.valLong(jv)), err
+}
+
+func (self *MethodHandle) InvokeFloat(env *Environment, obj *Object, params ...interface{}) (v float32, err error) {
+	jv, err := self.invokeKind(env, types.FloatKind, obj, params)
+	return float32(C.valFloat(jv)), err
+}
+
+func (self *MethodHandle) InvokeDouble(env *Environment, obj *Object, params ...interface{}) (v float64, err error) {
+	jv, err := self.invokeKind(env, types.DoubleKind, obj, params)
+	return float64(C.valDouble(jv)), err
+}
+
+// Invokes an object (or array) returning method;  the result is a local ref (nil for null).
+func (self *MethodHandle) InvokeObj(env *Environment, obj *Object, params ...interface{}) (v *Object, err error) {
+	k := self.method.sig.Return.Kind()
+	if k != types.ClassKind && k != types.ArrayKind {
+		return nil, ErrWrongKind
+	}
+	jv, err := self.invoke(env, obj, params)
+	if err == nil && C.valObject(jv) != nil {
+		v = newObject(C.valObject(jv))
+	}
+	return
+}
+
+// Constructs a new instance through a "<init>" handle;  as with NewInstance, the object is a global ref.
+func (self *MethodHandle) New(env *Environment, params ...interface{}) (o *Object, err error) {
+	if self.Name != "<init>" {
+		return nil, ErrUnknownMethod
+	}
+	args, localStack, err := newTypedArgList(env, self.method.sig.Params, params...)
+	if err != nil {
+		return
+	}
+	defer blowStack(env, localStack)
+	obj := C.envNewObjectA(env.env, self.class.class, self.method.method, args.Ptr())
+	if obj != nil {
+		o = newObject(C.envNewGlobalRef(env.env, obj))
+		C.envDeleteLocalRef(env.env, obj)
+	} else {
+		err = env.ExceptionOccurred()
+	}
+	return
+}
+
+/*
+	A field resolved once (see Class.Field) for repeated access;  as with
+	MethodHandle, it holds a global ref of its class until Release.
+*/
+type FieldHandle struct {
+	class  *Class
+	field  *Field
+	Name   string
+	Type   types.Typed
+	Static bool
+}
+
+/*
+	Resolves the named field of the class, of type t;  an instance field
+	is looked for first, then a static one.
+*/
+func (self *Class) Field(env *Environment, name string, t types.Typed) (h *FieldHandle, err error) {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	cform := C.CString(t.TypeString())
+	defer C.free(unsafe.Pointer(cform))
+
+	static := false
+	f := C.envGetFieldID(env.env, self.class, cname, cform)
+	if f == nil {
+		unmute := env.defMute()
+		env.ExceptionOccurred()
+		unmute()
+		static = true
+		f = C.envGetStaticFieldID(env.env, self.class, cname, cform)
+	}
+	if f == nil {
+		return nil, env.ExceptionOccurred()
+	}
+	return &FieldHandle{env.globalClass(self), &Field{f}, name, t, static}, nil
+}
+
+// Releases the handle's class ref;  the handle may not be used afterwards.
+func (self *FieldHandle) Release(env *Environment) {
+	if self.class != nil {
+		env.DeleteGlobalClassRef(self.class)
+		self.class = nil
+	}
+}
+
+func (self *FieldHandle) subject(obj *Object) (subject C.jobject, isStatic C.jboolean, err error) {
+	if self.Static {
+		return C.jobject(self.class.class), C.JNI_TRUE, nil
+	}
+	if obj == nil || obj.object == nil {
+		return nil, C.JNI_FALSE, ErrNullReceiver
+	}
+	return obj.object, C.JNI_FALSE, nil
+}
+
+/*
+	Returns the field's value on obj (nil for static fields), converted as
+	MethodHandle.Invoke converts results.
+*/
+func (self *FieldHandle) Get(env *Environment, obj *Object) (v interface{}, err error) {
+	subject, isStatic, err := self.subject(obj)
+	if err != nil {
+		return
+	}
+	jv := C.envGetFieldKind(env.env, subject, self.field.field, kindChar(self.Type), isStatic)
+	if env.ExceptionCheck() {
+		return nil, env.ExceptionOccurred()
+	}
+	return env.jvalueGo(self.Type, jv)
+}
+
+// Returns an object (or array) field's value on obj as is (no unboxing), as a local ref (nil for null)
+func (self *FieldHandle) GetObj(env *Environment, obj *Object) (v *Object, err error) {
+	k := self.Type.Kind()
+	if k != types.ClassKind && k != types.ArrayKind {
+		return nil, ErrWrongKind
+	}
+	subject, isStatic, err := self.subject(obj)
+	if err != nil {
+		return
+	}
+	jv := C.envGetFieldKind(env.env, subject, self.field.field, kindChar(self.Type), isStatic)
+	if env.ExceptionCheck() {
+		return nil, env.ExceptionOccurred()
+	}
+	if C.valObject(jv) != nil {
+		v = newObject(C.valObject(jv))
+	}
+	return
+}
+
+/*
+	Sets the field on obj (nil for static fields) to v, converted to the
+	field's type as MethodHandle parameters are (numbers are widened or
+	narrowed to the declared primitive, primitives boxed for wrapper fields).
+*/
+func (self *FieldHandle) Set(env *Environment, obj *Object, v interface{}) (err error) {
+	subject, isStatic, err := self.subject(obj)
+	if err != nil {
+		return
+	}
+	args, localStack, err := newTypedArgList(env, []types.Typed{self.Type}, v)
+	if err != nil {
+		return
+	}
+	defer blowStack(env, localStack)
+	C.envSetFieldKind(env.env, subject, self.field.field, kindChar(self.Type), isStatic, args[0])
+	if env.ExceptionCheck() {
+		err = env.ExceptionOccurred()
+	}
+	return
+}
+
+// converts a jvalue holding a value of the declared type t, as returned by handles
+func (self *Environment) jvalueGo(t types.Typed, jv C.jvalue) (v interface{}, err error) {
+	switch t.Kind() {
+	case types.VoidKind:
+	case types.BoolKind:
+		v = asBool(C.valBool(jv))
+	case types.ByteKind:
+		v = int8(C.valByte(jv))
+	case types.CharKind:
+		v = uint16(C.valChar(jv))
+	case types.ShortKind:
+		v = int16(C.valShort(jv))
+	case types.IntKind:
+		v = int(C.valInt(jv))
+	case types.LongKind:
+		v = int64(C.valLong(jv))
+	case types.FloatKind:
+		v = float32(C.valFloat(jv))
+	case types.DoubleKind:
+		v = float64(C.valDouble(jv))
+	default:
+		oval := C.valObject(jv)
+		if oval == nil {
+			return
+		}
+		obj := newObject(oval)
+		if c, ok := t.(types.Class); ok {
+			if _, boxed := types.UnboxedKind(c.Klass); boxed {
+				defer self.DeleteLocalRef(obj)
+				return self.Unbox(obj)
+			}
+		}
+		v = obj
+	}
+	return
+}
@@ -0,0 +1,336 @@
+package gojvm
+
+import (
+	"fmt"
+	"github.com/timob/gojvm/types"
+	"github.com/timob/gojvm/types/classfile"
+	"reflect"
+	"strings"
+)
+
+var goHandlerClass = types.Name{"org", "golang", "ext", "gojvm", "GoInvocationHandler"}
+var invocationHandlerClass = types.Name{"java", "lang", "reflect", "InvocationHandler"}
+var proxyClass = types.Name{"java", "lang", "reflect", "Proxy"}
+var javaLangUnsupportedOperationException = types.Name{"java", "lang", "UnsupportedOperationException"}
+
+var objectArray = types.Array{types.Class{types.JavaLangObject}}
+
+// GoInvocationHandler.invoke(Object, Method, Object[])
+var invokeSig = types.MethodSignature{
+	Params: []types.Typed{
+		types.Class{types.JavaLangObject},
+		types.Class{reflectMethodClass},
+		objectArray,
+	},
+	Return: types.Class{types.JavaLangObject},
+}
+
+// a Go value implementing an interface:  its methods by Java name and signature
+type goImplementation struct {
+	value   interface{}
+	methods map[string]callbackDescriptor
+}
+
+/*
+	Returns a java.lang.reflect.Proxy implementing the interface iface,
+	whose methods call those of the Go value impl:  the Java method "run"
+	calls impl's "Run", "compare" calls "Compare", and so on.  The Go methods
+	take the form of CallbackDescriptor, the *Object being the proxy:
+
+		func (self *Task) Run(env *Environment, proxy *Object)
+		func (self *ByLength) Compare(env *Environment, proxy *Object, a, b string) int
+
+	and are matched to the Java methods (including overloads) by name and
+	signature, as for RegisterNative;  arguments are unboxed (primitives),
+	converted to string (java/lang/String, if the Go method takes string) or
+	passed as local *Object refs, for the duration of the call.
+
+	Java methods without a Go match (Go methods of their name that aren't
+	such callbacks, as an ordinary Equals, are ignored) run their default
+	implementation, if they have one (Java 16+), or behave as Object's for
+	equals, hashCode and toString;  others throw UnsupportedOperationException.
+
+	The result is a global ref;  impl is kept (reachable) until Unimplement.
+*/
+func (self *Environment) Implement(iface string, impl interface{}) (proxy *Object, err error) {
+	class, err := self.GetClassStr(iface)
+	if err != nil {
+		return
+	}
+	info, err := self.Describe(class)
+	if err != nil {
+		return
+	}
+	defer info.Release(self)
+	gi := &goImplementation{value: impl, methods: map[string]callbackDescriptor{}}
+	rv := reflect.ValueOf(impl)
+	for _, mi := range info.Methods {
+		m := rv.MethodByName(strings.ToUpper(mi.Name[:1]) + mi.Name[1:])
+		if !m.IsValid() {
+			continue
+		}
+		cbd, cerr := CallbackDescriptor(self, m.Interface())
+		if cerr != nil || nativeConforms(mi.Signature, cbd.Signature) != nil {
+			continue
+		}
+		cbd.Signature = mi.Signature
+		gi.methods[mi.Name+mi.Signature.String()] = cbd
+	}
+	if len(gi.methods) == 0 {
+		return nil, ErrNoImplementation
+	}
+	handler, err := self.newGoHandler(gi)
+	if err != nil {
+		return
+	}
+	defer self.DeleteGlobalRef(handler)
+	return self.newProxy(class, handler)
+}
+
+/*
+	Releases the Go value behind a proxy from Implement (the proxy itself,
+	if global, must still be released with DeleteGlobalRef);  calls through
+	the proxy fail afterwards.
+*/
+func (self *Environment) Unimplement(proxy *Object) (err error) {
+	pclass, err := self.GetClass(proxyClass)
+	if err != nil {
+		return
+	}
+	handler, err := pclass.CallObj(self, true, "getInvocationHandler", types.Class{invocationHandlerClass}, proxy)
+	if err != nil {
+		return
+	}
+	defer self.DeleteLocalRef(handler)
+	hclass, err := self.GetClass(goHandlerClass)
+	if err != nil {
+		return
+	}
+	if !self.IsInstanceOf(handler, hclass) {
+		return ErrBadCast
+	}
+	id, err := handler.GetLongField(self, false, "id")
+	if err == nil {
+		self.jvm.unkeep(id)
+	}
+	return
+}
+
+// a new (global) GoInvocationHandler, dispatching to v
+func (self *Environment) newGoHandler(v interface{}) (handler *Object, err error) {
+	err = self.jvm.defineGoClass(self, goHandlerClass, goHandlerBytes(), goMethod{"invoke", invokeSig, self.jvm.invokeGo})
+	if err != nil {
+		return
+	}
+	class, err := self.GetClass(goHandlerClass)
+	if err != nil {
+		return
+	}
+	if handler, err = self.NewInstance(class); err != nil {
+		return
+	}
+	if err = handler.SetLongField(self, false, "id", self.jvm.keep(v)); err != nil {
+		self.DeleteGlobalRef(handler)
+		handler = nil
+	}
+	return
+}
+
+// Proxy.newProxyInstance(<the loader of iface>, new Class[]{iface}, handler), as a global ref
+func (self *Environment) newProxy(iface *Class, handler *Object) (proxy *Object, err error) {
+	loader, err := self.loaderOf(iface)
+	if err != nil {
+		return
+	}
+	if loader != nil {
+		defer self.DeleteLocalRef(loader)
+	}
+	cclass, err := self.GetClass(ClassClass)
+	if err != nil {
+		return
+	}
+	ifaces, err := self.newObjectArray(1, cclass, nil)
+	if err != nil {
+		return
+	}
+	defer self.DeleteLocalRef(ifaces)
+	self.setObjectArrayElement(ifaces, 0, iface.asObject())
+	pclass, err := self.GetClass(proxyClass)
+	if err != nil {
+		return
+	}
+	newProxy, err := pclass.Method(self, "newProxyInstance", types.MethodSignature{
+		Params: []types.Typed{
+			types.Class{classLoaderClass},
+			types.Array{types.Class{ClassClass}},
+			types.Class{invocationHandlerClass},
+		},
+		Return: types.Class{types.JavaLangObject},
+	})
+	if err != nil {
+		return
+	}
+	local, err := newProxy.InvokeObj(self, nil, loader, ifaces, handler)
+	if err != nil {
+		return
+	}
+	proxy = self.NewGlobalRef(local)
+	self.DeleteLocalRef(local)
+	return
+}
+
+// the native GoInvocationHandler.invoke
+func (self *JVM) invokeGo(env *Environment, handler, proxy, method, args *Object) *Object {
+	id, err := handler.GetLongField(env, false, "id")
+	if err != nil {
+		panic(err)
+	}
+	gi, ok := self.keptValue(id).(*goImplementation)
+	if !ok {
+		panic("invocation of a released Go implementation")
+	}
+	name, sig, err := env.reflectedSignature(method)
+	if err != nil {
+		panic(err)
+	}
+	params := env.ToObjectArray(args)
+	defer blowStack(env, params)
+	cbd, ok := gi.methods[name+sig.String()]
+	if !ok {
+		return env.invokeUnimplemented(gi, proxy, method, args, name, sig, params)
+	}
+
+	in := []reflect.Value{reflect.ValueOf(env), reflect.ValueOf(proxy)}
+	for i, p := range sig.Params {
+		v, err := env.unboxedParam(p, cbd.PTypes[i], params[i])
+		if err != nil {
+			panic(err)
+		}
+		in = append(in, v)
+	}
+	out := reflect.ValueOf(cbd.F).Call(in)
+	if len(out) == 0 || sig.Return.Kind() == types.VoidKind {
+		return nil
+	}
+	result, err := env.boxedResult(out[0])
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+// the name and signature of a reflected Method
+func (self *Environment) reflectedSignature(method *Object) (name string, sig types.MethodSignature, err error) {
+	if name, _, err = method.CallString(self, false, "getName"); err != nil {
+		return
+	}
+	if sig.Return, err = self.reflectedClassType(method, "getReturnType"); err != nil {
+		return
+	}
+	sig.Params = []types.Typed{}
+	err = self.eachReflected(method, "getParameterTypes", types.Class{ClassClass}, func(p *Object) (err error) {
+		var t types.Typed
+		if t, err = self.classObjectType(p); err == nil {
+			sig.Params = append(sig.Params, t)
+		}
+		return
+	})
+	return
+}
+
+// converts an element of a proxy's (boxed) argument array to the Go type pt
+func (self *Environment) unboxedParam(t types.Typed, pt reflect.Type, obj *Object) (v reflect.Value, err error) {
+	switch t.Kind() {
+	case types.ClassKind, types.ArrayKind:
+		if pt.Kind() != reflect.String {
+			return reflect.ValueOf(obj), nil
+		}
+		var s string
+		if s, _, err = self.ToString(obj); err == nil {
+			v = reflect.ValueOf(s)
+		}
+		return
+	}
+	u, err := self.Unbox(obj)
+	if err != nil {
+		return
+	}
+	v = reflect.ValueOf(u)
+	if !v.Type().ConvertibleTo(pt) {
+		return v, ErrWrongKind
+	}
+	return v.Convert(pt), nil
+}
+
+// a Go result as the Object a proxy method returns (boxed, if primitive), as a local ref
+func (self *Environment) boxedResult(out reflect.Value) (obj *Object, err error) {
+	switch v := out.Interface().(type) {
+	case string:
+		return self.localString(v)
+	case *Object:
+		return v, nil
+	}
+	if isPrimitive(out.Interface()) {
+		return self.Box(out.Interface())
+	}
+	return nil, ErrWrongKind
+}
+
+// calls of methods impl doesn't have:  defaults, Object's, or UnsupportedOperationException
+func (self *Environment) invokeUnimplemented(gi *goImplementation, proxy, method, args *Object, name string, sig types.MethodSignature, params []*Object) *Object {
+	if isDefault, err := method.CallBool(self, false, "isDefault"); err == nil && isDefault {
+		ihclass, err := self.GetClass(invocationHandlerClass)
+		if err != nil {
+			panic(err)
+		}
+		invokeDefault, err := ihclass.Method(self, "invokeDefault", types.MethodSignature{
+			Params: []types.Typed{types.Class{types.JavaLangObject}, types.Class{reflectMethodClass}, objectArray},
+			Return: types.Class{types.JavaLangObject},
+		})
+		if err == nil {
+			result, err := invokeDefault.InvokeObj(self, nil, proxy, method, args)
+			if err != nil {
+				panic(err)
+			}
+			return result
+		}
+	}
+	switch name + sig.String() {
+	case "equals(Ljava/lang/Object;)Z":
+		result, _ := self.Box(self.IsSameObject(proxy, params[0]))
+		return result
+	case "hashCode()I":
+		system, err := self.GetClassStr("java/lang/System")
+		if err != nil {
+			panic(err)
+		}
+		hash, err := system.CallInt(self, true, "identityHashCode", proxy)
+		if err != nil {
+			panic(err)
+		}
+		result, _ := self.Box(hash)
+		return result
+	case "toString()Ljava/lang/String;":
+		result, _ := self.localString(fmt.Sprintf("GoProxy[%T]", gi.value))
+		return result
+	}
+	self.ThrowNew(javaLangUnsupportedOperationException, fmt.Sprintf("%T has no method for %s%s", gi.value, name, sig.String()))
+	return nil
+}
+
+/*
+	assembles:
+
+		public final class org.golang.ext.gojvm.GoInvocationHandler implements InvocationHandler {
+			public long id;
+			public GoInvocationHandler() { super(); }
+			public native Object invoke(Object proxy, Method method, Object[] args);
+		}
+*/
+func goHandlerBytes() []byte {
+	a := classfile.NewWriter()
+	a.Field(classfile.AccPublic, "id", "J")
+	a.DefaultConstructor(types.JavaLangObject.AsPath())
+	a.NativeMethod(classfile.AccPublic|classfile.AccNative, "invoke", invokeSig.String())
+	return a.Bytes(classfile.AccPublic|classfile.AccFinal|classfile.AccSuper, goHandlerClass.AsPath(), types.JavaLangObject.AsPath(), invocationHandlerClass.AsPath())
+}
@@ -1,4 +1,4 @@
-#include</usr/lib/jvm/default-java/include/jni.h>
+#include<jni.h>
 #include<string.h>
 #include<stdlib.h>
 #include<libio.h>
@@ -33,6 +33,7 @@ void envDeleteLocalRef(JNIEnv *env, jobject obj) ;
 
 
 jclass 		envFindClass(JNIEnv *, const char *);
+jclass		envDefineClass(JNIEnv *, const char *, jobject, const void *, jsize);
 jmethodID envGetMethodID(JNIEnv *, jobject, const char *, const char *);
 jmethodID envGetStaticMethodID(JNIEnv *env, jclass jobj, const char *meth, const char *sig);
 
@@ -50,6 +51,17 @@ jobject		envGetObjectArrayElement(JNIEnv *env, jobjectArray, jsize);
 
 jbyteArray	envNewByteArray(JNIEnv *env, jsize len);
 void 				envSetByteArrayRegion(JNIEnv *env, jbyteArray array, jsize start, jsize len, const void *buf); 
+void 				envGetByteArrayRegion(JNIEnv *env, jbyteArray array, jsize start, jsize len, void *buf); 
+
+jintArray		envNewIntArray(JNIEnv *env, jsize len);
+void 				envSetIntArrayRegion(JNIEnv *env, jintArray array, jsize start, jsize len, const void *buf); 
+
+jlongArray	envNewLongArray(JNIEnv *env, jsize len);
+void 				envSetLongArrayRegion(JNIEnv *env, jlongArray array, jsize start, jsize len, const void *buf); 
+
+jcharArray	envNewCharArray(JNIEnv *env, jsize len);
+void 				envSetCharArrayRegion(JNIEnv *env, jcharArray array, jsize start, jsize len, const void *buf); 
+void 				envGetCharArrayRegion(JNIEnv *env, jcharArray array, jsize start, jsize len, void *buf); 
 
 jfloat		envCallFloatMethodA(JNIEnv *, jobject, jmethodID, void *);
 jfloat		envCallStaticFloatMethodA(JNIEnv *, jobject, jmethodID, void *);
@@ -60,6 +72,12 @@ jdouble		envCallStaticDoubleMethodA(JNIEnv *, jobject, jmethodID, void *);
 jboolean	envCallBoolMethodA(JNIEnv *, jobject, jmethodID, void *);
 jboolean	envCallStaticBoolMethodA(JNIEnv *, jobject, jmethodID, void *);
 
+jbyte		envCallByteMethodA(JNIEnv *, jobject, jmethodID, void *);
+jbyte		envCallStaticByteMethodA(JNIEnv *, jclass, jmethodID, void *);
+
+jchar		envCallCharMethodA(JNIEnv *, jobject, jmethodID, void *);
+jchar		envCallStaticCharMethodA(JNIEnv *, jclass, jmethodID, void *);
+
 jshort		envCallShortMethodA(JNIEnv *, jobject, jmethodID, void *);
 jshort		envCallStaticShortMethodA(JNIEnv *, jclass, jmethodID, void *);
 
@@ -75,13 +93,30 @@ jobject		envCallStaticObjectMethodA(JNIEnv *, jclass, jmethodID, void *);
 void			envCallVoidMethodA(JNIEnv *, jobject, jmethodID, void *);
 void			envCallStaticVoidMethodA(JNIEnv *, jclass, jmethodID, void *);
 
+void			envCallNonvirtualVoidMethodA(JNIEnv *, jobject, jclass, jmethodID, void *);
+jboolean	envCallNonvirtualBoolMethodA(JNIEnv *, jobject, jclass, jmethodID, void *);
+jbyte			envCallNonvirtualByteMethodA(JNIEnv *, jobject, jclass, jmethodID, void *);
+jchar			envCallNonvirtualCharMethodA(JNIEnv *, jobject, jclass, jmethodID, void *);
+jshort		envCallNonvirtualShortMethodA(JNIEnv *, jobject, jclass, jmethodID, void *);
+jint			envCallNonvirtualIntMethodA(JNIEnv *, jobject, jclass, jmethodID, void *);
+jlong			envCallNonvirtualLongMethodA(JNIEnv *, jobject, jclass, jmethodID, void *);
+jfloat		envCallNonvirtualFloatMethodA(JNIEnv *, jobject, jclass, jmethodID, void *);
+jdouble		envCallNonvirtualDoubleMethodA(JNIEnv *, jobject, jclass, jmethodID, void *);
+jobject		envCallNonvirtualObjectMethodA(JNIEnv *, jobject, jclass, jmethodID, void *);
+
 jint			envGetArrayLength(JNIEnv *, jobject);
 jobject		envNewGlobalRef(JNIEnv *, jobject);
+void		envDeleteGlobalRef(JNIEnv *, jobject);
 
 jobject		envNewObjectA(JNIEnv *, jclass, jmethodID, void *);
 jobject		envNewObjectALP(JNIEnv *, jclass, jmethodID, ArgListPtr);
 
 jboolean	envIsSameObject(JNIEnv *, jobject, jobject);
+jboolean	envIsInstanceOf(JNIEnv *, jobject, jclass);
+jboolean	envIsAssignableFrom(JNIEnv *, jclass, jclass);
+jclass		envGetSuperclass(JNIEnv *, jclass);
+jmethodID	envFromReflectedMethod(JNIEnv *, jobject);
+jfieldID	envFromReflectedField(JNIEnv *, jobject);
 
 jbyte			*envGetByteArrayElements(JNIEnv *, jobject, jboolean *);
 void			envReleaseByteArrayElements(JNIEnv *, jobject, jbyte *, jint); 
@@ -144,19 +179,33 @@ void envSetDoubleField(JNIEnv *env, jclass clazz, jfieldID fieldID, jdouble val)
 
 
 
+// kind-dispatched calls & field access (kind is the JNI type character: 'V','Z','B',..,'L','[')
+jvalue	envCallMethodKindA(JNIEnv *, jobject, jmethodID, char, jboolean, void *);
+jvalue	envGetFieldKind(JNIEnv *, jobject, jfieldID, char, jboolean);
+void		envSetFieldKind(JNIEnv *, jobject, jfieldID, char, jboolean, jvalue);
+
 // internal helpers
 int		addStringArgument(JavaVMInitArgs *args, const char *string);
+int		addHookArgument(JavaVMInitArgs *args, const char *hook);
+void	freeJVMArgs(JavaVMInitArgs *args);
 // vm Calls
 // env is actually a void **, but we allow void to make CGo easier
 // cleaner solutions welcome! :)
+int		loadLibJVM(const char *);
+const char	*libJVMError();
 jint	newJVMContext(JavaVM **, void *, JavaVMInitArgs *);
+jint	existingJVMs(JavaVM **, jsize, jsize *);
 jint  vmAttachCurrentThread(JavaVM *jvm, void *env, void *args);
 jint 	vmDetachCurrentThread(JavaVM *jvm);
+jint	vmGetEnv(JavaVM *jvm, void *env, jint version);
+jint	vmDestroyJavaVM(JavaVM *jvm);
 
 
 jint  envGetJavaVM(JNIEnv	*, JavaVM **);
+jint  envGetVersion(JNIEnv *);
 jint  envRegisterNative(JNIEnv *, jclass, char *, char *, void *);
 jint  envUnregisterNatives(JNIEnv *, jclass);
+jint  envThrowNew(JNIEnv *, jclass, const char *);
 
 
 
@@ -173,19 +222,27 @@ jvalue  objValue(jobject v);
 
 
 
-void  voidCallback0(JNIEnv *, jobject, void* );
-void  voidCallback1(JNIEnv *, jobject, void* );
+// Go natives:  trampolines (see jvm_natives.c) spill the registers and stack
+// slots a JNI call may use into NativeArgs, for goNative to decode by signature
+#if defined(__x86_64__) && !defined(_WIN32)
+#define NATIVE_INT_REGS	4
+#elif defined(__aarch64__) && !defined(__APPLE__)
+#define NATIVE_INT_REGS	6
+#else
+#define NATIVE_INT_REGS	0
+#endif
+#define NATIVE_FP_REGS	8
+#define NATIVE_STACK	16
+#define NATIVE_SLOTS	256
+
+typedef struct {
+	jlong		ints[NATIVE_INT_REGS > 0 ? NATIVE_INT_REGS : 1];
+	jdouble	fps[NATIVE_FP_REGS];
+	jlong		stack[NATIVE_STACK];
+	jvalue	ret;
+}	NativeArgs;
 
-void *generified0(JNIEnv *env, jobject obj, ...);
-void *generified1(JNIEnv *env, jobject obj, ...);
-void *generified2(JNIEnv *env, jobject obj, ...);
-void *generified3(JNIEnv *env, jobject obj, ...);
-void *generified4(JNIEnv *env, jobject obj, ...);
-void *generified5(JNIEnv *env, jobject obj, ...);
-void *generified6(JNIEnv *env, jobject obj, ...);
-void *generified7(JNIEnv *env, jobject obj, ...);
-void *generified8(JNIEnv *env, jobject obj, ...);
-void *generified9(JNIEnv *env, jobject obj, ...);
+void	*nativeTrampoline(int slot, jboolean fp);
 
 
 jboolean  valBool(jvalue v) ;
@@ -1,7 +0,0 @@
-package org.golang.ext.gojvm.testing;
-
-class Pathos {
-	Pathos() throws Exception {
-		throw new Exception("Mwahahahahaa");
-	}
-}
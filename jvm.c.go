@@ -1,12 +1,29 @@
+/*
+	Go bindings of the Java Native Interface:  create (or adopt) a JVM, and
+	call into it, or be called from it, through an Environment.
+
+	Building needs the JDK's JNI headers (jni.h, and jni_md.h from its
+	platform directory), which cgo is told of through CGO_CFLAGS, e.g., for a
+	Linux JDK:
+
+		export CGO_CFLAGS="-I$JAVA_HOME/include -I$JAVA_HOME/include/linux"
+		go build github.com/timob/gojvm
+
+	(darwin, win32 ... for other platforms).  Nothing is linked against
+	libjvm;  it is found and loaded at run time (see JvmConfig.JavaHome).
+*/
 package gojvm
 
+//#cgo LDFLAGS:-ldl
 //#include "helpers.h"
 import "C"
 
 import (
 	"errors"
-	"github.com/timob/gojvm/types"
+	"os"
+	"sort"
 	"strings"
+	"sync"
 	"unsafe"
 	"runtime"
 )
@@ -14,93 +31,348 @@ import (
 
 type JVM struct {
 	jvm        *C.JavaVM
+	// the callbacks of Go natives by trampoline slot (guarded by lock);  the
+	// next unused slot, the methods bound to slots and the slots given back
+	// (see registerGoNative), and their guard
 	registered map[int]callbackDescriptor
 	regId      int
+	natives    []nativeSlot
+	freeSlots  []int
+	nativeLock sync.Mutex
+	// the loader of AddClassPath entries, and the home loader (global refs),
+	// whether Destroy was called, and their guard
+	classPath  *Object
+	home       *Object
+	destroyed  bool
+	lock       sync.Mutex
+	// Go values referenced from Java objects by id (see keep), and the
+	// classes defined for them (see defineGoClass, Subclass) with their guard
+	kept       map[int64]interface{}
+	keptId     int64
+	defined    map[string]bool
+	subclasses int
+	defineLock sync.Mutex
 }
 
-func (self *JVM) addNative(env *Environment, f interface{}) (id int, csig types.MethodSignature, err error) {
-	//func CallbackSignature(f interface{})(sig MethodSignature, err error){
-	cbd, err := CallbackDescriptor(env, f)
-	if err != nil {
-		return
+func newJVM(vm *C.JavaVM) *JVM {
+	return &JVM{
+		jvm:        vm,
+		registered: map[int]callbackDescriptor{},
 	}
-	/// todo, this is not thread safe...
-	id = self.regId
-	self.registered[id] = cbd
-	self.regId++
-	csig = cbd.Signature
-	//log.Printf("Calculated signature: %v", csig)
-	/// TODO
-	return
+}
+
+/*
+	Returns the JVM already running in this process, as when Go is loaded
+	(as a shared library) into a Java process;  threads use it through
+	AttachCurrentThread.  The JVM is registered in AllVMs (and if it already
+	was, as for one from NewJVM, that *JVM is returned).
+*/
+func ExistingJVM() (jvm *JVM, err error) {
+	var vm *C.JavaVM
+	var n C.jsize
+	res := C.existingJVMs(&vm, 1, &n)
+	if res == -2 {
+		// libjvm isn't in the process's symbols;  load it ourselves
+		if err = loadLibJVM(JvmConfig{}); err != nil {
+			return
+		}
+		res = C.existingJVMs(&vm, 1, &n)
+	}
+	if res != C.JNI_OK || n == 0 || vm == nil {
+		return nil, ErrNoJVM
+	}
+	return AllVMs.adopt(vm), nil
 }
 
 /* 
 	Returns a new environment pointer that is appropriate for the /currently executing/
 	thread to use.  It is safe to call multiply (idempotent), and can be returned 
 	via DetachCurrentThread (not idempotent!)
+
+	The calling goroutine is locked to its thread until it detaches;  if the
+	thread is already attached, its Environment is returned as is (and one
+	DetachCurrentThread still undoes it all).  Once the JVM is destroyed,
+	ErrNoJVM is returned.
 */
 func (self *JVM) AttachCurrentThread() (env *Environment, err error) {
-	env = NewEnvironment(self)
+	if self.isDestroyed() {
+		return nil, ErrNoJVM
+	}
 	runtime.LockOSThread()
+	var p *C.JNIEnv
+	if C.vmGetEnv(self.jvm, unsafe.Pointer(&p), C.JNI_VERSION_1_6) == C.JNI_OK {
+		if env = AllEnvs.Find(uintptr(unsafe.Pointer(p))); env != nil {
+			// locked when it attached
+			runtime.UnlockOSThread()
+			return
+		}
+	}
+	env = NewEnvironment(self)
 	//print ("Allocated environment for thread\t", env.Ptr(),"\n")
 	if 0 != C.vmAttachCurrentThread(self.jvm, env.Ptr(), nil) {
+		runtime.UnlockOSThread()
 		err = errors.New("Couldn't attach thread (and thus cannot gather exception)")
 	} else {
 		AllEnvs.Add(env)
+		if home := self.localHome(env); home != nil {
+			err = env.setContextClassLoader(home)
+			env.DeleteLocalRef(home)
+		}
 	}
 	return
 }
 
+/*
+	Returns the Environment of the calling thread, attaching it if it was
+	not already attached (attached is then true, for doneEnv to undo).
+*/
+func (self *JVM) currentEnv() (env *Environment, attached bool, err error) {
+	if self.isDestroyed() {
+		return nil, false, ErrNoJVM
+	}
+	var p *C.JNIEnv
+	if C.vmGetEnv(self.jvm, unsafe.Pointer(&p), C.JNI_VERSION_1_6) == C.JNI_OK {
+		if env = AllEnvs.Find(uintptr(unsafe.Pointer(p))); env != nil {
+			return
+		}
+	}
+	env, err = self.AttachCurrentThread()
+	return env, err == nil, err
+}
+
+// detaches the calling thread again, if currentEnv attached it
+func (self *JVM) doneEnv(attached bool) {
+	if attached {
+		self.DetachCurrentThread()
+	}
+}
+
+// true once Destroy was called
+func (self *JVM) isDestroyed() bool {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	return self.destroyed
+}
+
 // notifies the JVM of your threads done-ness w/ it, and deallocates the associated
 // environment pointer.  Depending on the exact JDK version, there are differing semantics
 // on whether the 'original' thread can call this (else JVM Shutdown), but most modern
 // stacks (>=1.2) should allow this from the 'main' thread.
+//
+// The thread's Environment is released (its cached class refs are deleted) and
+// forgotten, and must not be used again;  the goroutine is unlocked from the thread.
+// So classes from GetClass on this thread die with it:  take a NewGlobalRef of
+// any that must outlive it (method and field handles, Bindings, DefineClass and
+// Subclass results already hold refs of their own).
 func (self *JVM) DetachCurrentThread() (err error) {
+	defer runtime.UnlockOSThread()
+	var p *C.JNIEnv
+	if C.vmGetEnv(self.jvm, unsafe.Pointer(&p), C.JNI_VERSION_1_6) == C.JNI_OK {
+		if env := AllEnvs.Find(uintptr(unsafe.Pointer(p))); env != nil {
+			env.releaseCache(env)
+			AllEnvs.Remove(env)
+		}
+	}
 	if 0 != C.vmDetachCurrentThread(self.jvm) {
 		err = errors.New("Couldn't attach thread (and thus cannot gather exception)")
 	}
 	return
 }
 
+/*
+	Shuts the JVM down.  The cached global refs of every Environment of the
+	JVM (in any thread), and those of the class path and home loaders, are
+	released, and the Environments are forgotten;  then DestroyJavaVM unloads
+	the VM, after running its shutdown hooks.
+
+	DestroyJavaVM waits for every non-daemon Java thread to finish, and this
+	includes Go threads still attached (other than the caller);  they should
+	DetachCurrentThread first.  Nothing from the JVM may be used afterwards
+	(the JVM's own methods return ErrNoJVM), and (a limitation of HotSpot) no
+	new JVM can be created in the process.
+*/
+func (self *JVM) Destroy() (err error) {
+	env, _, err := self.currentEnv()
+	if err != nil {
+		return
+	}
+	self.lock.Lock()
+	if self.destroyed {
+		self.lock.Unlock()
+		return ErrNoJVM
+	}
+	self.destroyed = true
+	for _, o := range []*Object{self.classPath, self.home} {
+		if o != nil {
+			env.DeleteGlobalRef(o)
+		}
+	}
+	self.classPath, self.home = nil, nil
+	self.lock.Unlock()
+	for _, other := range AllEnvs.owned(self) {
+		env.releaseCache(other)
+		AllEnvs.Remove(other)
+	}
+	res := C.vmDestroyJavaVM(self.jvm)
+	// the calling thread was detached by DestroyJavaVM
+	runtime.UnlockOSThread()
+	// shutdown hooks calling Go natives may have re-registered both
+	for _, other := range AllEnvs.owned(self) {
+		AllEnvs.Remove(other)
+	}
+	AllVMs.Remove(self)
+	if res != C.JNI_OK {
+		err = ErrDestroyFailed
+	}
+	return
+}
+
+/*
+	Configures a new JVM;  all but ClassPath may be left zero.  Options are
+	passed as given (e.g., "-Xcheck:jni", "-XX:+UseG1GC", "--add-opens=..."),
+	after those derived from the other fields.
+*/
 type JvmConfig struct {
 	ClassPath []string
+	// the JDK (or JRE) whose libjvm is loaded;  found if empty (see FindLibJVM)
+	JavaHome string
+	// capture the main thread's context class loader as the JVM's home
+	// loader (see JVM.SetHomeLoader)
+	HomeLoader bool
+
+	Options    []string
+	Properties map[string]string // as -Dkey=value
+	// heap sizes in the -Xmx/-Xms syntax (e.g., "512m", "2g")
+	MaxHeap     string
+	InitialHeap string
+	// skip (rather than fail on) options the JVM does not recognize
+	IgnoreUnrecognized bool
+	// Java 9+ modules, as --module-path & --add-modules
+	ModulePath []string
+	AddModules []string
+
+	// receives the JVM's diagnostic output (one formatted chunk per call,
+	// usually a line) instead of stderr
+	OnLog func(msg string)
+	// called when the JVM exits the process (as for System.exit);  the
+	// process still exits once it returns, so this is the chance to flush
+	OnExit func(code int)
+	// called when the JVM aborts (a crash);  the process aborts after
+	OnAbort func()
 }
 
+// the option strings for the JVM the config describes
+func (self JvmConfig) options() (opts []string) {
+	opts = append(opts, "-Djava.class.path="+strings.Join(self.ClassPath, string(os.PathListSeparator)))
+	keys := make([]string, 0, len(self.Properties))
+	for k := range self.Properties {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		opts = append(opts, "-D"+k+"="+self.Properties[k])
+	}
+	if self.InitialHeap != "" {
+		opts = append(opts, "-Xms"+self.InitialHeap)
+	}
+	if self.MaxHeap != "" {
+		opts = append(opts, "-Xmx"+self.MaxHeap)
+	}
+	if len(self.ModulePath) > 0 {
+		opts = append(opts, "--module-path="+strings.Join(self.ModulePath, string(os.PathListSeparator)))
+	}
+	if len(self.AddModules) > 0 {
+		opts = append(opts, "--add-modules="+strings.Join(self.AddModules, ","))
+	}
+	return append(opts, self.Options...)
+}
+
+/*
+	Creates the JVM conf describes, with the calling thread (locked to the
+	goroutine) as its main thread.  On failure the thread is unlocked again.
+*/
 func NewJVM(ver int, conf JvmConfig) (jvm *JVM, env *Environment, err error) {
 	runtime.LockOSThread()
+	defer func() {
+		if jvm == nil {
+			runtime.UnlockOSThread()
+		}
+	}()
 
-	args, err := defaultJVMArgs(ver)
-	if err != nil {
+	if err = loadLibJVM(conf); err != nil {
 		return
 	}
-	pathStr := strings.Join(conf.ClassPath, ":")
-	//print("Adding class path\n")
-	err = addStringArg(args, "-Djava.class.path="+pathStr)
-	if err == nil {
-		//print("Initializing JVM Context\n")
-		jvm = &JVM{
-			registered: map[int]callbackDescriptor{},
+	args := newJVMArgs(ver)
+	defer C.freeJVMArgs(args)
+	for _, opt := range conf.options() {
+		if err = addStringArg(args, opt); err != nil {
+			return
 		}
-		env = NewEnvironment(jvm)
-		if 0 != C.newJVMContext(&jvm.jvm, env.Ptr(), args) {
-			err = errors.New("Couldn't instantiate JVM")
-		} else {
-			AllVMs.Add(jvm)
+	}
+	if err = addHooks(args, conf); err != nil {
+		return
+	}
+	if conf.IgnoreUnrecognized {
+		args.ignoreUnrecognized = C.JNI_TRUE
+	}
+	//print("Initializing JVM Context\n")
+	jvm = newJVM(nil)
+	env = NewEnvironment(jvm)
+	if 0 != C.newJVMContext(&jvm.jvm, env.Ptr(), args) {
+		jvm, env, err = nil, nil, errors.New("Couldn't instantiate JVM")
+	} else {
+		AllVMs.Add(jvm)
+		AllEnvs.Add(env)
+		if conf.HomeLoader {
+			err = jvm.captureHomeLoader(env)
 		}
 	}
 	return
 }
 
-func defaultJVMArgs(ver int) (args *C.JavaVMInitArgs, err error) {
+// the libjvm loaded, once it is
+var libJVMPath string
+
+// dlopens the libjvm conf selects (see FindLibJVM);  later calls are no-ops,
+// as a process only ever hosts one JVM library.
+func loadLibJVM(conf JvmConfig) (err error) {
+	if libJVMPath != "" {
+		return
+	}
+	path, err := FindLibJVM(conf)
+	if err != nil {
+		return
+	}
+	cpath := C.CString(path)
+	defer C.free(unsafe.Pointer(cpath))
+	if 0 != C.loadLibJVM(cpath) {
+		return errors.New("Couldn't load " + path + ": " + C.GoString(C.libJVMError()))
+	}
+	libJVMPath = path
+	return
+}
+
+// sets the home loader to the context class loader of env's thread
+func (self *JVM) captureHomeLoader(env *Environment) (err error) {
+	loader, err := env.contextClassLoader()
+	if err != nil || loader.object == nil {
+		return
+	}
+	defer env.DeleteLocalRef(loader)
+	return self.SetHomeLoader(env, loader)
+}
+
+// empty JVM arguments for JNI version ver (or DEFAULT_JVM_VERSION, if 0);
+// options are added with addStringArg.
+func newJVMArgs(ver int) (args *C.JavaVMInitArgs) {
 	if ver == 0 {
 		ver = DEFAULT_JVM_VERSION
 	}
 	args = new(C.JavaVMInitArgs)
-	//print("Default args\t", ver,"\n")
 	args.version = C.jint(ver)
-	if 0 != C.JNI_GetDefaultJavaVMInitArgs(unsafe.Pointer(args)) {
-		err = errors.New("Couldn't contruct default JVM args")
-	}
+	args.nOptions = 0
+	args.options = nil
+	args.ignoreUnrecognized = C.JNI_FALSE
 	return
 }
 
@@ -113,3 +385,56 @@ func addStringArg(args *C.JavaVMInitArgs, s string) (err error) {
 	}
 	return
 }
+
+// the JvmConfig hooks of the (only) JVM of the process
+var vmHooks struct {
+	onLog   func(string)
+	onExit  func(int)
+	onAbort func()
+}
+
+// adds the vfprintf/exit/abort options for the hooks conf sets
+func addHooks(args *C.JavaVMInitArgs, conf JvmConfig) (err error) {
+	vmHooks.onLog, vmHooks.onExit, vmHooks.onAbort = conf.OnLog, conf.OnExit, conf.OnAbort
+	hooks := []struct {
+		name string
+		set  bool
+	}{
+		{"vfprintf", conf.OnLog != nil},
+		{"exit", conf.OnExit != nil},
+		{"abort", conf.OnAbort != nil},
+	}
+	for _, hook := range hooks {
+		if !hook.set {
+			continue
+		}
+		chook := C.CString(hook.name)
+		ok := C.addHookArgument(args, chook)
+		C.free(unsafe.Pointer(chook))
+		if ok != 0 {
+			return errors.New("addHookArgument failed for " + hook.name)
+		}
+	}
+	return
+}
+
+//export goVMLog
+func goVMLog(msg *C.char) {
+	if vmHooks.onLog != nil {
+		vmHooks.onLog(C.GoString(msg))
+	}
+}
+
+//export goVMExit
+func goVMExit(code C.jint) {
+	if vmHooks.onExit != nil {
+		vmHooks.onExit(int(code))
+	}
+}
+
+//export goVMAbort
+func goVMAbort() {
+	if vmHooks.onAbort != nil {
+		vmHooks.onAbort()
+	}
+}
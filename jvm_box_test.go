@@ -0,0 +1,86 @@
+package gojvm
+
+import (
+	"github.com/timob/gojvm/types"
+	"testing"
+)
+
+var BoxingClass = "org/golang/ext/gojvm/testing/Boxing"
+/* Methods declared with wrapper (and wrapper super-type) parameters and returns;
+
+Verifies:
+	Overload matching via (un)boxing and reference widening
+	Matched overloads are cached per class, name and parameter types
+	Unboxed returns through Call*
+*/
+
+var boxTests = []interface{}{
+	true,
+	int8(-3),
+	uint16('x'),
+	int16(-5128),
+	int(15),
+	int64(1) << 40,
+	float32(.25),
+	float64(-1234.5),
+}
+
+func TestJVMBoxUnbox(t *testing.T) {
+	env := setupJVM(t)
+	for i, v := range boxTests {
+		obj, err := env.Box(v)
+		fatalIf(t, err != nil, "[%d] Couldn't box %T: %v", i, v, err)
+		uv, err := env.Unbox(obj)
+		fatalIf(t, err != nil, "[%d] Couldn't unbox %T: %v", i, v, err)
+		fatalInEq(t, v, uv, "[%d] Wrong unboxed value", i)
+		env.DeleteLocalRef(obj)
+	}
+	_, err := env.Box("str")
+	fatalIf(t, err != ErrNotBoxed, "Boxing a string should fail (got %v)", err)
+}
+
+func TestJVMBoxedCalls(t *testing.T) {
+	env := setupJVM(t)
+	obj, err := env.NewInstanceStr(BoxingClass)
+	fatalIf(t, err != nil, "Couldn't instantiate Boxing: %v", err)
+	defer env.DeleteLocalRef(obj)
+
+	i, err := obj.CallInt(env, false, "BoxedInt")
+	fatalIf(t, err != nil, "Couldn't call BoxedInt: %v", err)
+	fatalInEq(t, 42, i, "Wrong BoxedInt")
+
+	i, err = obj.CallInt(env, false, "AddBoxed", 3, 4)
+	fatalIf(t, err != nil, "Couldn't call AddBoxed: %v", err)
+	fatalInEq(t, 7, i, "Wrong AddBoxed")
+
+	s, _, err := obj.CallString(env, false, "Describe", int64(5))
+	fatalIf(t, err != nil, "Couldn't call Describe: %v", err)
+	fatalInEq(t, "java.lang.Long", s, "Wrong boxed class")
+
+	d, err := obj.CallDouble(env, false, "Half", float32(3))
+	fatalIf(t, err != nil, "Couldn't call Half: %v", err)
+	fatalInEq(t, 1.5, d, "Wrong Half")
+
+	v, err := obj.Call(env, false, "BoxedObject", types.Class{types.JavaLangObject})
+	fatalIf(t, err != nil, "Couldn't call BoxedObject: %v", err)
+	fatalInEq(t, int64(7), v, "Wrong BoxedObject")
+
+	v, err = obj.Call(env, false, "BoxedInt", types.Class{types.JavaLangInteger})
+	fatalIf(t, err != nil, "Couldn't call BoxedInt: %v", err)
+	fatalInEq(t, 42, v, "Wrong BoxedInt via Call")
+}
+
+func TestJVMOverloadCache(t *testing.T) {
+	env := setupJVM(t)
+	obj, err := env.NewInstanceStr(BoxingClass)
+	fatalIf(t, err != nil, "Couldn't instantiate Boxing: %v", err)
+	defer env.DeleteLocalRef(obj)
+	for i := 0; i < 2; i++ {
+		n, err := obj.CallInt(env, false, "AddBoxed", i, 1)
+		fatalIf(t, err != nil, "[%d] Couldn't call AddBoxed: %v", i, err)
+		fatalInEq(t, i+1, n, "[%d] Wrong AddBoxed", i)
+	}
+	msig, ok := env.overloads[BoxingClass+".AddBoxed(II)I"]
+	fatalIf(t, !ok, "AddBoxed's overload wasn't cached (%v)", env.overloads)
+	fatalInEq(t, "(Ljava/lang/Integer;Ljava/lang/Integer;)I", msig.String(), "Wrong cached overload")
+}
@@ -0,0 +1,80 @@
+package gojvm
+
+import (
+	"testing"
+)
+
+var CharsClass = "org/golang/ext/gojvm/testing/Chars"
+/* byte & char fields, returns and arrays;
+
+Verifies:
+	byte <=> int8, char <=> uint16 through calls, fields and arrays
+	runes are accepted for char parameters
+	null arrays come back as nil slices, without an error
+*/
+
+func TestJVMCharByteCalls(t *testing.T) {
+	env := setupJVM(t)
+	obj, err := env.NewInstanceStr(CharsClass)
+	fatalIf(t, err != nil, "Couldn't instantiate Chars: %v", err)
+	defer env.DeleteLocalRef(obj)
+
+	b, err := obj.CallByte(env, false, "GetB")
+	fatalIf(t, err != nil, "Couldn't call GetB: %v", err)
+	fatalInEq(t, int8(-7), b, "Wrong byte")
+	c, err := obj.CallChar(env, false, "GetC")
+	fatalIf(t, err != nil, "Couldn't call GetC: %v", err)
+	fatalInEq(t, 'x', rune(c), "Wrong char")
+
+	bs, err := obj.CallByteArray(env, false, "Echo", []int8{-1, 0, 1})
+	fatalIf(t, err != nil, "Couldn't call Echo(byte[]): %v", err)
+	fatalIf(t, len(bs) != 3 || bs[0] != -1 || bs[2] != 1, "Wrong byte array: %v", bs)
+	cs, err := obj.CallCharArray(env, false, "Echo", []uint16{'h', 'i'})
+	fatalIf(t, err != nil, "Couldn't call Echo(char[]): %v", err)
+	fatalInEq(t, "hi", string([]rune{rune(cs[0]), rune(cs[1])}), "Wrong char array")
+
+	c, err = obj.CallChar(env, false, "Next", 'a')
+	fatalIf(t, err != nil, "Couldn't call Next with a rune: %v", err)
+	fatalInEq(t, 'b', rune(c), "Wrong Next")
+	cs, err = obj.CallCharArray(env, false, "NoChars")
+	fatalIf(t, err != nil, "A null char[] shouldn't be an error: %v", err)
+	fatalIf(t, cs != nil, "Expected a nil slice for a null char[], got %v", cs)
+}
+
+func TestJVMCharByteFields(t *testing.T) {
+	env := setupJVM(t)
+	obj, err := env.NewInstanceStr(CharsClass)
+	fatalIf(t, err != nil, "Couldn't instantiate Chars: %v", err)
+	defer env.DeleteLocalRef(obj)
+
+	err = obj.SetByteField(env, false, "B", 100)
+	fatalIf(t, err != nil, "Couldn't set B: %v", err)
+	b, err := obj.GetByteField(env, false, "B")
+	fatalIf(t, err != nil, "Couldn't get B: %v", err)
+	fatalInEq(t, int8(100), b, "Wrong byte field")
+
+	err = obj.SetCharField(env, false, "C", uint16('κ'))
+	fatalIf(t, err != nil, "Couldn't set C: %v", err)
+	c, err := obj.GetCharField(env, false, "C")
+	fatalIf(t, err != nil, "Couldn't get C: %v", err)
+	fatalInEq(t, 'κ', rune(c), "Wrong char field")
+
+	bs, err := obj.GetByteArrayField(env, false, "Bytes")
+	fatalIf(t, err != nil, "Couldn't get Bytes: %v", err)
+	fatalIf(t, len(bs) != 3 || bs[1] != -2, "Wrong byte array field: %v", bs)
+	cs, err := obj.GetCharArrayField(env, false, "Letters")
+	fatalIf(t, err != nil, "Couldn't get Letters: %v", err)
+	fatalIf(t, len(cs) != 2 || cs[0] != 'g', "Wrong char array field: %v", cs)
+	bs, err = obj.GetByteArrayField(env, false, "NoBytes")
+	fatalIf(t, err != nil, "A null byte[] field shouldn't be an error: %v", err)
+	fatalIf(t, bs != nil, "Expected a nil slice for a null byte[] field, got %v", bs)
+
+	klass, err := env.GetClassStr(CharsClass)
+	fatalIf(t, err != nil, "Couldn't get Chars class: %v", err)
+	b, err = klass.GetByteField(env, true, "StaticB")
+	fatalIf(t, err != nil, "Couldn't get StaticB: %v", err)
+	fatalInEq(t, int8(5), b, "Wrong static byte field")
+	c, err = klass.GetCharField(env, true, "StaticC")
+	fatalIf(t, err != nil, "Couldn't get StaticC: %v", err)
+	fatalInEq(t, 'j', rune(c), "Wrong static char field")
+}
@@ -0,0 +1,75 @@
+package gojvm
+
+import (
+	"github.com/timob/gojvm/types"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+/* Class directories written at test time (holding classes assembled by
+definedClassBytes, see jvm_define_test.go), which are not on the class path;
+
+Verifies:
+	JVM.AddClassPath extends GetClass
+	NewClassLoader & GetClassFrom load in isolation
+*/
+
+// writes the class 'name' into a new temporary class directory
+func classDir(t *testing.T, name string) string {
+	dir, err := ioutil.TempDir("", "gojvm")
+	fatalIf(t, err != nil, "Couldn't make class dir: %v", err)
+	file := filepath.Join(dir, filepath.FromSlash(name)+".class")
+	err = os.MkdirAll(filepath.Dir(file), 0755)
+	fatalIf(t, err != nil, "Couldn't make package dir: %v", err)
+	err = ioutil.WriteFile(file, definedClassBytes(name), 0644)
+	fatalIf(t, err != nil, "Couldn't write class: %v", err)
+	return dir
+}
+
+func TestJVMAddClassPath(t *testing.T) {
+	env := setupJVM(t)
+	name := "org/golang/ext/gojvm/testing/Appended"
+	dir := classDir(t, name)
+	defer os.RemoveAll(dir)
+
+	err := _jvm.AddClassPath(dir)
+	fatalIf(t, err != nil, "Couldn't add class path: %v", err)
+	klass, err := env.GetClassStr(name)
+	fatalIf(t, err != nil, "Couldn't get appended class: %v", err)
+	_, err = env.NewInstance(klass)
+	fatalIf(t, err != nil, "Couldn't instantiate appended class: %v", err)
+
+	// the startup class path is still searched first
+	_, err = env.GetClassStr(SystemClass)
+	fatalIf(t, err != nil, "Couldn't get System: %v", err)
+}
+
+func TestJVMClassLoaderIsolation(t *testing.T) {
+	env := setupJVM(t)
+	name := "org/golang/ext/gojvm/testing/Isolated"
+	dir := classDir(t, name)
+	defer os.RemoveAll(dir)
+
+	loader, err := env.NewClassLoader(dir)
+	fatalIf(t, err != nil, "Couldn't make class loader: %v", err)
+	defer env.DeleteGlobalRef(loader)
+	other, err := env.NewClassLoader(dir)
+	fatalIf(t, err != nil, "Couldn't make class loader: %v", err)
+	defer env.DeleteGlobalRef(other)
+
+	a, err := env.GetClassFrom(loader, types.NewName(name))
+	fatalIf(t, err != nil, "Couldn't load isolated class: %v", err)
+	defer env.DeleteGlobalClassRef(a)
+	b, err := env.GetClassFrom(other, types.NewName(name))
+	fatalIf(t, err != nil, "Couldn't load isolated class: %v", err)
+	defer env.DeleteGlobalClassRef(b)
+	fatalIf(t, env.IsSameObject(a.asObject(), b.asObject()), "Each loader should define its own class")
+
+	func() {
+		defer env.defMute()()
+		_, err = env.GetClassStr(name)
+	}()
+	fatalIf(t, err == nil, "Isolated class shouldn't be visible to GetClass")
+}
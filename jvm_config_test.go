@@ -0,0 +1,61 @@
+package gojvm
+
+import (
+	"testing"
+)
+
+/* The JVM option strings derived from a JvmConfig (no JVM is needed), and the
+JNI version and lookup of the running JVM;
+
+Verifies:
+	Each field's option, and their order
+	env.Version
+	ExistingJVM finds the JVM NewJVM created
+*/
+
+func TestJvmConfigOptions(t *testing.T) {
+	conf := JvmConfig{
+		ClassPath:   []string{"a.jar", "classes"},
+		Options:     []string{"-Xcheck:jni"},
+		Properties:  map[string]string{"b.prop": "2", "a.prop": "1"},
+		MaxHeap:     "512m",
+		InitialHeap: "64m",
+		ModulePath:  []string{"mods"},
+		AddModules:  []string{"java.sql", "java.xml"},
+	}
+	expected := []string{
+		"-Djava.class.path=a.jar:classes",
+		"-Da.prop=1",
+		"-Db.prop=2",
+		"-Xms64m",
+		"-Xmx512m",
+		"--module-path=mods",
+		"--add-modules=java.sql,java.xml",
+		"-Xcheck:jni",
+	}
+	opts := conf.options()
+	fatalInEq(t, len(expected), len(opts), "Wrong option count in %v", opts)
+	for i, opt := range expected {
+		fatalInEq(t, opt, opts[i], "[%d] Wrong option", i)
+	}
+
+	opts = JvmConfig{}.options()
+	fatalIf(t, len(opts) != 1 || opts[0] != "-Djava.class.path=", "Empty config should only set the class path (%v)", opts)
+}
+
+func TestJVMVersion(t *testing.T) {
+	env := setupJVM(t)
+	v := env.Version()
+	fatalIf(t, v < DEFAULT_JVM_VERSION, "JNI version %#x is older than requested (%#x)", v, DEFAULT_JVM_VERSION)
+}
+
+func TestJVMExisting(t *testing.T) {
+	setupJVM(t)
+	jvm, err := ExistingJVM()
+	fatalIf(t, err != nil, "Couldn't find the running JVM: %v", err)
+	fatalIf(t, jvm != _jvm, "ExistingJVM should return the registered JVM")
+	env, err := jvm.AttachCurrentThread()
+	fatalIf(t, err != nil, "Couldn't attach through the existing JVM: %v", err)
+	_, err = env.GetClassStr(SystemClass)
+	fatalIf(t, err != nil, "Couldn't use the attached environment: %v", err)
+}
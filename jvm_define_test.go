@@ -0,0 +1,69 @@
+package gojvm
+
+import (
+	"github.com/timob/gojvm/types"
+	"github.com/timob/gojvm/types/classfile"
+	"testing"
+)
+
+var DefinedClass = "org/golang/ext/gojvm/testing/Defined"
+/* A class assembled with the classfile Writer (with only a public no-arg
+constructor), defined from bytes rather than loaded from the java/ tree;
+
+Verifies:
+	DefineClass, and the caching of defined classes by GetClass
+	The defined class is the caller's own ref (apart from the cached one)
+	Classes defined in another loader are not cached
+	Redefinition fails
+*/
+
+// assembles 'public class <name> { public <name>(){ super(); } }'
+func definedClassBytes(name string) []byte {
+	w := classfile.NewWriter()
+	w.DefaultConstructor("java/lang/Object")
+	return w.Bytes(classfile.AccPublic|classfile.AccSuper, name, "java/lang/Object")
+}
+
+func TestJVMDefineClass(t *testing.T) {
+	env := setupJVM(t)
+	name := types.NewName(DefinedClass)
+	klass, err := env.DefineClass(name, nil, definedClassBytes(DefinedClass))
+	fatalIf(t, err != nil, "Couldn't define class: %v", err)
+	defer env.DeleteGlobalClassRef(klass)
+
+	cname, err := klass.GetName(env)
+	fatalIf(t, err != nil, "Couldn't name defined class: %v", err)
+	fatalInEq(t, DefinedClass, cname.AsPath(), "Wrong defined name")
+
+	found, err := env.GetClass(name)
+	fatalIf(t, err != nil, "Couldn't get defined class: %v", err)
+	fatalIf(t, !env.IsSameObject(found.asObject(), klass.asObject()), "GetClass should return the defined class")
+	fatalIf(t, found == klass, "DefineClass should return a ref apart from the cached one")
+
+	obj, err := env.NewInstance(klass)
+	fatalIf(t, err != nil, "Couldn't instantiate defined class: %v", err)
+	fatalIf(t, !env.IsInstanceOf(obj, klass), "Instance of the wrong class")
+
+	defer env.defMute()()
+	_, err = env.DefineClass(name, nil, definedClassBytes(DefinedClass))
+	fatalIf(t, err == nil, "Redefining a class should fail")
+}
+
+func TestJVMDefineClassInLoader(t *testing.T) {
+	env := setupJVM(t)
+	loader, err := env.NewClassLoader()
+	fatalIf(t, err != nil, "Couldn't make a class loader: %v", err)
+	defer env.DeleteGlobalRef(loader)
+
+	name := types.NewName(DefinedClass + "Apart")
+	klass, err := env.DefineClass(name, loader, definedClassBytes(name.AsPath()))
+	fatalIf(t, err != nil, "Couldn't define class in a loader: %v", err)
+	defer env.DeleteGlobalClassRef(klass)
+	obj, err := env.NewInstance(klass)
+	fatalIf(t, err != nil, "Couldn't instantiate class defined in a loader: %v", err)
+	env.DeleteGlobalRef(obj)
+
+	defer env.defMute()()
+	_, err = env.GetClass(name)
+	fatalIf(t, err == nil, "GetClass shouldn't find a class defined in another loader")
+}
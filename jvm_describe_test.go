@@ -0,0 +1,69 @@
+package gojvm
+
+import (
+	"github.com/timob/gojvm/types"
+	"testing"
+)
+
+/* Describes the Handles fixture (see jvm_handle_test.go) and java.util.ArrayList;
+
+Verifies:
+	ClassInfo supers, interfaces, members & modifiers
+	Handles resolved from reflected members
+*/
+
+func TestJVMDescribe(t *testing.T) {
+	env := setupJVM(t)
+	klass, err := env.GetClassStr(HandlesClass)
+	fatalIf(t, err != nil, "Couldn't get Handles: %v", err)
+	info, err := env.Describe(klass)
+	fatalIf(t, err != nil, "Couldn't describe Handles: %v", err)
+	defer info.Release(env)
+
+	fatalInEq(t, HandlesClass, info.Name.AsPath(), "Wrong name")
+	fatalInEq(t, "java/lang/Object", info.Superclass.AsPath(), "Wrong superclass")
+	fatalInEq(t, 0, len(info.Interfaces), "Handles has no interfaces")
+	fatalInEq(t, 1, len(info.Constructors), "Wrong constructor count")
+	fatalInEq(t, "(Ljava/lang/String;)V", info.Constructors[0].Signature.String(), "Wrong constructor signature")
+
+	add, ok := info.Method("Add")
+	fatalIf(t, !ok, "Add wasn't described")
+	fatalInEq(t, "(J)J", add.Signature.String(), "Wrong Add signature")
+	fatalInEq(t, HandlesClass, add.DeclaringClass.AsPath(), "Wrong Add declaring class")
+	fatalIf(t, add.Modifiers.Has(ModStatic), "Add isn't static")
+
+	bump, ok := info.Method("Bump")
+	fatalIf(t, !ok, "Bump wasn't described")
+	fatalIf(t, !bump.Modifiers.Has(ModStatic), "Bump is static")
+	_, err = bump.Handle.InvokeInt(env, nil)
+	fatalIf(t, err != nil, "Couldn't invoke described Bump: %v", err)
+
+	// inherited public methods are listed along with declared ones
+	hash, ok := info.Method("hashCode")
+	fatalIf(t, !ok, "hashCode wasn't described")
+	fatalInEq(t, "java/lang/Object", hash.DeclaringClass.AsPath(), "Wrong hashCode declaring class")
+
+	boxed, ok := info.Field("Boxed")
+	fatalIf(t, !ok, "Boxed wasn't described")
+	fatalInEq(t, types.Class{types.JavaLangInteger}.TypeString(), boxed.Type.TypeString(), "Wrong Boxed type")
+	count, ok := info.Field("Count")
+	fatalIf(t, !ok || !count.Handle.Static, "Count wasn't described as static")
+}
+
+func TestJVMDescribeInterfaces(t *testing.T) {
+	env := setupJVM(t)
+	klass, err := env.GetClassStr("java/util/ArrayList")
+	fatalIf(t, err != nil, "Couldn't get ArrayList: %v", err)
+	info, err := env.Describe(klass)
+	fatalIf(t, err != nil, "Couldn't describe ArrayList: %v", err)
+	defer info.Release(env)
+	fatalIf(t, !info.Modifiers.Has(ModPublic), "ArrayList is public (%s)", info.Modifiers)
+	fatalInEq(t, "java/util/AbstractList", info.Superclass.AsPath(), "Wrong superclass")
+	found := false
+	for _, iface := range info.Interfaces {
+		found = found || iface.AsPath() == "java/util/List"
+	}
+	fatalIf(t, !found, "List not among interfaces %v", info.Interfaces)
+
+	fatalInEq(t, "public static final", (ModPublic | ModStatic | ModFinal).String(), "Wrong modifier string")
+}
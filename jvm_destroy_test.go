@@ -0,0 +1,77 @@
+package gojvm
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+	"unsafe"
+)
+
+/* Tears down a JVM;  as a JVM can't be recreated in a process, Destroy runs
+in a child test process (GOJVM_DESTROY set), leaving the shared _jvm alone;
+
+Verifies:
+	Attaching an attached thread returns its Environment
+	DetachCurrentThread forgets the thread's Environment
+	Destroy releases and forgets every Environment, and the JVM
+	A destroyed JVM refuses to be used (or destroyed) again
+*/
+
+func TestJVMDetachForgets(t *testing.T) {
+	setupJVM(t)
+	done := make(chan bool)
+	go func() {
+		tenv, err := _jvm.AttachCurrentThread()
+		if err != nil {
+			done <- false
+			return
+		}
+		tenv.GetClassStr(SystemClass)
+		_jvm.DetachCurrentThread()
+		done <- AllEnvs.Find(uintptr(unsafe.Pointer(tenv.env))) == nil && len(tenv.classes) == 0
+	}()
+	fatalIf(t, !<-done, "Detached environment is still registered, or holds class refs")
+}
+
+func TestJVMAttachTwice(t *testing.T) {
+	setupJVM(t)
+	done := make(chan bool)
+	go func() {
+		before := len(AllEnvs.owned(_jvm))
+		first, err := _jvm.AttachCurrentThread()
+		if err != nil {
+			done <- false
+			return
+		}
+		second, err := _jvm.AttachCurrentThread()
+		same := err == nil && first == second && len(AllEnvs.owned(_jvm)) == before+1
+		_jvm.DetachCurrentThread()
+		done <- same
+	}()
+	fatalIf(t, !<-done, "Attaching an attached thread should return its Environment")
+}
+
+func TestJVMDestroy(t *testing.T) {
+	if os.Getenv("GOJVM_DESTROY") == "" {
+		cmd := exec.Command(os.Args[0], "-test.run=TestJVMDestroy")
+		cmd.Env = append(os.Environ(), "GOJVM_DESTROY=1")
+		out, err := cmd.CombinedOutput()
+		fatalIf(t, err != nil, "Destroying child failed: %v\n%s", err, out)
+		return
+	}
+	env := setupJVM(t)
+	_, err := env.GetClassStr(SystemClass)
+	fatalIf(t, err != nil, "Couldn't load System: %v", err)
+	jvm := _jvm
+	err = jvm.Destroy()
+	fatalIf(t, err != nil, "Couldn't destroy the JVM: %v", err)
+	fatalIf(t, len(AllEnvs.owned(jvm)) != 0, "Environments of the destroyed JVM are still registered")
+	fatalIf(t, AllVMs.Find(uintptr(unsafe.Pointer(jvm.jvm))) != nil, "Destroyed JVM is still registered")
+
+	err = jvm.Destroy()
+	fatalIf(t, err != ErrNoJVM, "Destroying twice should fail (got %v)", err)
+	_, err = jvm.AttachCurrentThread()
+	fatalIf(t, err != ErrNoJVM, "Attaching to a destroyed JVM should fail (got %v)", err)
+	err = jvm.AddClassPath(".")
+	fatalIf(t, err != ErrNoJVM, "Using a destroyed JVM should fail (got %v)", err)
+}
@@ -20,6 +20,9 @@ jobject envNewLocalRef(JNIEnv *env, jobject obj) { return (*env)->NewLocalRef(en
 void envDeleteLocalRef(JNIEnv *env, jobject obj) { (*env)->DeleteLocalRef(env, obj); }   
 
 jclass envFindClass(JNIEnv *env, const char *string){ return  (*env)->FindClass(env, string); }
+jclass envDefineClass(JNIEnv *env, const char *name, jobject loader, const void *buf, jsize len){
+	return (*env)->DefineClass(env, name, loader, (const jbyte *)buf, len);
+}
 
 jmethodID envGetMethodID(JNIEnv *env, jobject jobj, const char *meth, const char *sig){ return  (*env)->GetMethodID(env, jobj, meth, sig); }
 
@@ -32,6 +35,12 @@ jclass	envGetObjectClass(JNIEnv *env, jobject jobj){ return (*env)->GetObjectCla
 jint    envCallIntMethodA(JNIEnv *env, jobject o, jmethodID m, void *val){ return (*env)->CallIntMethodA(env,o,m,val); }
 jint			envCallStaticIntMethodA(JNIEnv *env, jclass o, jmethodID m, void *val){ return (*env)->CallStaticIntMethodA(env,o,m,val); }
 
+jbyte    envCallByteMethodA(JNIEnv *env, jobject o, jmethodID m, void *val){ return (*env)->CallByteMethodA(env,o,m,val); }
+jbyte		envCallStaticByteMethodA(JNIEnv *env, jclass o, jmethodID m, void *val){ return (*env)->CallStaticByteMethodA(env,o,m,val); }
+
+jchar    envCallCharMethodA(JNIEnv *env, jobject o, jmethodID m, void *val){ return (*env)->CallCharMethodA(env,o,m,val); }
+jchar		envCallStaticCharMethodA(JNIEnv *env, jclass o, jmethodID m, void *val){ return (*env)->CallStaticCharMethodA(env,o,m,val); }
+
 jshort    envCallShortMethodA(JNIEnv *env, jobject o, jmethodID m, void *val){ return (*env)->CallShortMethodA(env,o,m,val); }
 jshort		envCallStaticShortMethodA(JNIEnv *env, jclass o, jmethodID m, void *val){ return (*env)->CallStaticShortMethodA(env,o,m,val); }
 
@@ -54,6 +63,18 @@ jboolean	envCallStaticBoolMethodA(JNIEnv *env, jclass o, jmethodID m, void *val)
 void	envCallStaticVoidMethodA(JNIEnv *env, jclass o, jmethodID m, void *val){ (*env)->CallStaticVoidMethodA(env,o,m,val); }
 void	envCallVoidMethodA(JNIEnv *env, jobject o, jmethodID m, void *val){ (*env)->CallVoidMethodA(env,o,m,val); }
 
+// CallNonvirtualXXXMethodA
+void	envCallNonvirtualVoidMethodA(JNIEnv *env, jobject o, jclass c, jmethodID m, void *val){ (*env)->CallNonvirtualVoidMethodA(env,o,c,m,val); }
+jboolean	envCallNonvirtualBoolMethodA(JNIEnv *env, jobject o, jclass c, jmethodID m, void *val){ return (*env)->CallNonvirtualBooleanMethodA(env,o,c,m,val); }
+jbyte	envCallNonvirtualByteMethodA(JNIEnv *env, jobject o, jclass c, jmethodID m, void *val){ return (*env)->CallNonvirtualByteMethodA(env,o,c,m,val); }
+jchar	envCallNonvirtualCharMethodA(JNIEnv *env, jobject o, jclass c, jmethodID m, void *val){ return (*env)->CallNonvirtualCharMethodA(env,o,c,m,val); }
+jshort	envCallNonvirtualShortMethodA(JNIEnv *env, jobject o, jclass c, jmethodID m, void *val){ return (*env)->CallNonvirtualShortMethodA(env,o,c,m,val); }
+jint	envCallNonvirtualIntMethodA(JNIEnv *env, jobject o, jclass c, jmethodID m, void *val){ return (*env)->CallNonvirtualIntMethodA(env,o,c,m,val); }
+jlong	envCallNonvirtualLongMethodA(JNIEnv *env, jobject o, jclass c, jmethodID m, void *val){ return (*env)->CallNonvirtualLongMethodA(env,o,c,m,val); }
+jfloat	envCallNonvirtualFloatMethodA(JNIEnv *env, jobject o, jclass c, jmethodID m, void *val){ return (*env)->CallNonvirtualFloatMethodA(env,o,c,m,val); }
+jdouble	envCallNonvirtualDoubleMethodA(JNIEnv *env, jobject o, jclass c, jmethodID m, void *val){ return (*env)->CallNonvirtualDoubleMethodA(env,o,c,m,val); }
+jobject	envCallNonvirtualObjectMethodA(JNIEnv *env, jobject o, jclass c, jmethodID m, void *val){ return (*env)->CallNonvirtualObjectMethodA(env,o,c,m,val); }
+
 jint    envGetArrayLength(JNIEnv *env, jobject o){ return (*env)->GetArrayLength(env,o); }
 
 jbyte     *envGetByteArrayElements(JNIEnv *env, jobject o, jboolean *b){
@@ -85,10 +106,34 @@ jboolean  envIsSameObject(JNIEnv *env, jobject o, jobject o2){
 	return (*env)->IsSameObject(env,o, o2);
 }
 
+jboolean  envIsInstanceOf(JNIEnv *env, jobject o, jclass c){
+	return (*env)->IsInstanceOf(env, o, c);
+}
+
+jboolean  envIsAssignableFrom(JNIEnv *env, jclass sub, jclass sup){
+	return (*env)->IsAssignableFrom(env, sub, sup);
+}
+
+jclass  envGetSuperclass(JNIEnv *env, jclass c){
+	return (*env)->GetSuperclass(env, c);
+}
+
+jmethodID envFromReflectedMethod(JNIEnv *env, jobject method){
+	return (*env)->FromReflectedMethod(env, method);
+}
+
+jfieldID envFromReflectedField(JNIEnv *env, jobject field){
+	return (*env)->FromReflectedField(env, field);
+}
+
 jobject	envNewGlobalRef(JNIEnv *env, jobject o){
 	return (*env)->NewGlobalRef(env,o);
 }
 
+void	envDeleteGlobalRef(JNIEnv *env, jobject o){
+	(*env)->DeleteGlobalRef(env,o);
+}
+
 jobject	envNewObjectA(JNIEnv *env, jobject o, jmethodID meth, void *jv){
 	return (*env)->NewObjectA(env,o, meth, jv);
 }
@@ -133,6 +178,38 @@ void        envSetByteArrayRegion(JNIEnv *env, jbyteArray array, jsize start, js
 	(*env)->SetByteArrayRegion(env, array, start, len, buf);
 }
 
+void        envGetByteArrayRegion(JNIEnv *env, jbyteArray array, jsize start, jsize len, void *buf){
+	(*env)->GetByteArrayRegion(env, array, start, len, buf);
+}
+
+jintArray  envNewIntArray(JNIEnv *env, jsize len){
+	return (*env)->NewIntArray(env,len);
+}
+
+void        envSetIntArrayRegion(JNIEnv *env, jintArray array, jsize start, jsize len, const void *buf){
+	(*env)->SetIntArrayRegion(env, array, start, len, buf);
+}
+
+jlongArray  envNewLongArray(JNIEnv *env, jsize len){
+	return (*env)->NewLongArray(env,len);
+}
+
+void        envSetLongArrayRegion(JNIEnv *env, jlongArray array, jsize start, jsize len, const void *buf){
+	(*env)->SetLongArrayRegion(env, array, start, len, buf);
+}
+
+jcharArray  envNewCharArray(JNIEnv *env, jsize len){
+	return (*env)->NewCharArray(env,len);
+}
+
+void        envSetCharArrayRegion(JNIEnv *env, jcharArray array, jsize start, jsize len, const void *buf){
+	(*env)->SetCharArrayRegion(env, array, start, len, buf);
+}
+
+void        envGetCharArrayRegion(JNIEnv *env, jcharArray array, jsize start, jsize len, void *buf){
+	(*env)->GetCharArrayRegion(env, array, start, len, buf);
+}
+
 //fields
 jfieldID envGetStaticFieldID(JNIEnv *env, jclass clazz, const char *name, const char *sig) {
 	return (*env)->GetStaticFieldID(env, clazz, name, sig);
@@ -150,6 +227,10 @@ jbyte envGetStaticByteField(JNIEnv *env, jclass clazz, jfieldID fieldID) {
 	return (*env)->GetStaticByteField(env, clazz, fieldID);
 }
 
+jchar envGetStaticCharField(JNIEnv *env, jclass clazz, jfieldID fieldID) {
+	return (*env)->GetStaticCharField(env, clazz, fieldID);
+}
+
 jshort envGetStaticShortField(JNIEnv *env, jclass clazz, jfieldID fieldID) {
 	return (*env)->GetStaticShortField(env, clazz, fieldID);
 }
@@ -189,6 +270,10 @@ jbyte envGetByteField(JNIEnv *env, jclass clazz, jfieldID fieldID) {
 	return (*env)->GetByteField(env, clazz, fieldID);
 }
 
+jchar envGetCharField(JNIEnv *env, jclass clazz, jfieldID fieldID) {
+	return (*env)->GetCharField(env, clazz, fieldID);
+}
+
 jshort envGetShortField(JNIEnv *env, jclass clazz, jfieldID fieldID) {
 	return (*env)->GetShortField(env, clazz, fieldID);
 }
@@ -223,6 +308,10 @@ void envSetByteField(JNIEnv *env, jclass clazz, jfieldID fieldID, jbyte val) {
 	return (*env)->SetByteField(env, clazz, fieldID, val);
 }
 
+void envSetCharField(JNIEnv *env, jclass clazz, jfieldID fieldID, jchar val) {
+	return (*env)->SetCharField(env, clazz, fieldID, val);
+}
+
 void envSetShortField(JNIEnv *env, jclass clazz, jfieldID fieldID, jshort val) {
 	return (*env)->SetShortField(env, clazz, fieldID, val);
 }
@@ -255,6 +344,10 @@ void envSetStaticByteField(JNIEnv *env, jclass clazz, jfieldID fieldID, jbyte va
 	return (*env)->SetStaticByteField(env, clazz, fieldID, val);
 }
 
+void envSetStaticCharField(JNIEnv *env, jclass clazz, jfieldID fieldID, jchar val) {
+	return (*env)->SetStaticCharField(env, clazz, fieldID, val);
+}
+
 void envSetStaticShortField(JNIEnv *env, jclass clazz, jfieldID fieldID, jshort val) {
 	return (*env)->SetStaticShortField(env, clazz, fieldID, val);
 }
@@ -276,6 +369,100 @@ void envSetStaticDoubleField(JNIEnv *env, jclass clazz, jfieldID fieldID, jdoubl
 }
 
 
+/* kind-dispatched calls & fields;  these let a resolved ID be used
+   without a Go-side switch per type.  'o' is the jclass when isStatic. */
+jvalue envCallMethodKindA(JNIEnv *env, jobject o, jmethodID m, char kind, jboolean isStatic, void *val){
+	jvalue jv;
+	jv.j = 0;
+	if (isStatic) {
+		switch (kind) {
+		case 'V': (*env)->CallStaticVoidMethodA(env, o, m, val); break;
+		case 'Z': jv.z = (*env)->CallStaticBooleanMethodA(env, o, m, val); break;
+		case 'B': jv.b = (*env)->CallStaticByteMethodA(env, o, m, val); break;
+		case 'C': jv.c = (*env)->CallStaticCharMethodA(env, o, m, val); break;
+		case 'S': jv.s = (*env)->CallStaticShortMethodA(env, o, m, val); break;
+		case 'I': jv.i = (*env)->CallStaticIntMethodA(env, o, m, val); break;
+		case 'J': jv.j = (*env)->CallStaticLongMethodA(env, o, m, val); break;
+		case 'F': jv.f = (*env)->CallStaticFloatMethodA(env, o, m, val); break;
+		case 'D': jv.d = (*env)->CallStaticDoubleMethodA(env, o, m, val); break;
+		default:  jv.l = (*env)->CallStaticObjectMethodA(env, o, m, val); break;
+		}
+	} else {
+		switch (kind) {
+		case 'V': (*env)->CallVoidMethodA(env, o, m, val); break;
+		case 'Z': jv.z = (*env)->CallBooleanMethodA(env, o, m, val); break;
+		case 'B': jv.b = (*env)->CallByteMethodA(env, o, m, val); break;
+		case 'C': jv.c = (*env)->CallCharMethodA(env, o, m, val); break;
+		case 'S': jv.s = (*env)->CallShortMethodA(env, o, m, val); break;
+		case 'I': jv.i = (*env)->CallIntMethodA(env, o, m, val); break;
+		case 'J': jv.j = (*env)->CallLongMethodA(env, o, m, val); break;
+		case 'F': jv.f = (*env)->CallFloatMethodA(env, o, m, val); break;
+		case 'D': jv.d = (*env)->CallDoubleMethodA(env, o, m, val); break;
+		default:  jv.l = (*env)->CallObjectMethodA(env, o, m, val); break;
+		}
+	}
+	return jv;
+}
+
+jvalue envGetFieldKind(JNIEnv *env, jobject o, jfieldID f, char kind, jboolean isStatic){
+	jvalue jv;
+	jv.j = 0;
+	if (isStatic) {
+		switch (kind) {
+		case 'Z': jv.z = (*env)->GetStaticBooleanField(env, o, f); break;
+		case 'B': jv.b = (*env)->GetStaticByteField(env, o, f); break;
+		case 'C': jv.c = (*env)->GetStaticCharField(env, o, f); break;
+		case 'S': jv.s = (*env)->GetStaticShortField(env, o, f); break;
+		case 'I': jv.i = (*env)->GetStaticIntField(env, o, f); break;
+		case 'J': jv.j = (*env)->GetStaticLongField(env, o, f); break;
+		case 'F': jv.f = (*env)->GetStaticFloatField(env, o, f); break;
+		case 'D': jv.d = (*env)->GetStaticDoubleField(env, o, f); break;
+		default:  jv.l = (*env)->GetStaticObjectField(env, o, f); break;
+		}
+	} else {
+		switch (kind) {
+		case 'Z': jv.z = (*env)->GetBooleanField(env, o, f); break;
+		case 'B': jv.b = (*env)->GetByteField(env, o, f); break;
+		case 'C': jv.c = (*env)->GetCharField(env, o, f); break;
+		case 'S': jv.s = (*env)->GetShortField(env, o, f); break;
+		case 'I': jv.i = (*env)->GetIntField(env, o, f); break;
+		case 'J': jv.j = (*env)->GetLongField(env, o, f); break;
+		case 'F': jv.f = (*env)->GetFloatField(env, o, f); break;
+		case 'D': jv.d = (*env)->GetDoubleField(env, o, f); break;
+		default:  jv.l = (*env)->GetObjectField(env, o, f); break;
+		}
+	}
+	return jv;
+}
+
+void envSetFieldKind(JNIEnv *env, jobject o, jfieldID f, char kind, jboolean isStatic, jvalue v){
+	if (isStatic) {
+		switch (kind) {
+		case 'Z': (*env)->SetStaticBooleanField(env, o, f, v.z); break;
+		case 'B': (*env)->SetStaticByteField(env, o, f, v.b); break;
+		case 'C': (*env)->SetStaticCharField(env, o, f, v.c); break;
+		case 'S': (*env)->SetStaticShortField(env, o, f, v.s); break;
+		case 'I': (*env)->SetStaticIntField(env, o, f, v.i); break;
+		case 'J': (*env)->SetStaticLongField(env, o, f, v.j); break;
+		case 'F': (*env)->SetStaticFloatField(env, o, f, v.f); break;
+		case 'D': (*env)->SetStaticDoubleField(env, o, f, v.d); break;
+		default:  (*env)->SetStaticObjectField(env, o, f, v.l); break;
+		}
+	} else {
+		switch (kind) {
+		case 'Z': (*env)->SetBooleanField(env, o, f, v.z); break;
+		case 'B': (*env)->SetByteField(env, o, f, v.b); break;
+		case 'C': (*env)->SetCharField(env, o, f, v.c); break;
+		case 'S': (*env)->SetShortField(env, o, f, v.s); break;
+		case 'I': (*env)->SetIntField(env, o, f, v.i); break;
+		case 'J': (*env)->SetLongField(env, o, f, v.j); break;
+		case 'F': (*env)->SetFloatField(env, o, f, v.f); break;
+		case 'D': (*env)->SetDoubleField(env, o, f, v.d); break;
+		default:  (*env)->SetObjectField(env, o, f, v.l); break;
+		}
+	}
+}
+
 /*
 
 typedef struct {
@@ -292,18 +479,10 @@ jint	envGetJavaVM(JNIEnv  *env,JavaVM **jvm){
 	return (*env)->GetJavaVM(env, jvm);
 }
 
+jint	envGetVersion(JNIEnv *env){
+	return (*env)->GetVersion(env);
+}
 
-void *ret(GoInterface _if){ return _if.v; }
-
-/*void  *genericCallback0(JNIEnv *env, jobject obj, void *argp){ return ret(goCallback((uintptr)env, (uintptr) obj, 0, (uintptr) argp)); }
-void  *genericCallback1(JNIEnv *env, jobject obj, void *argp){ return ret(goCallback((uintptr)env, (uintptr) obj, 1, (uintptr) argp)); }
-void  *genericCallback2(JNIEnv *env, jobject obj, void *argp){ return ret(goCallback((uintptr)env, (uintptr) obj, 2, (uintptr) argp)); }
-void  *genericCallback3(JNIEnv *env, jobject obj, void *argp){ return ret(goCallback((uintptr)env, (uintptr) obj, 3, (uintptr) argp)); }
-void  *genericCallback4(JNIEnv *env, jobject obj, void *argp){ return ret(goCallback((uintptr)env, (uintptr) obj, 4, (uintptr) argp)); }
-void  *genericCallback5(JNIEnv *env, jobject obj, void *argp){ return ret(goCallback((uintptr)env, (uintptr) obj, 5, (uintptr) argp)); }
-void  *genericCallback6(JNIEnv *env, jobject obj, void *argp){ return ret(goCallback((uintptr)env, (uintptr) obj, 6, (uintptr) argp)); }
-void  *genericCallback7(JNIEnv *env, jobject obj, void *argp){ return ret(goCallback((uintptr)env, (uintptr) obj, 7, (uintptr) argp)); }
-*/
 
 /*
 
@@ -339,14 +518,6 @@ void delArgList(ArgListPtr alp){
 	free(alp);
 }
 
-void *doReturn(JNIEnv *env, struct goCallback_return ret){
-	if (ret.r0) {
-		return ret.r1.v;
-	}
-	printf("Failed, need to throw\n");
-	return;
-}
-
 jint envRegisterNative(JNIEnv *env, jclass	klass, char *funcName, char *signature, void* fnPtr ){
 	JNINativeMethod native;
 
@@ -360,4 +531,8 @@ jint envUnregisterNatives(JNIEnv  *env, jclass klass){
 	return (*env)->UnregisterNatives(env, klass);
 }
 
+jint envThrowNew(JNIEnv *env, jclass klass, const char *msg){
+	return (*env)->ThrowNew(env, klass, msg);
+}
+
 
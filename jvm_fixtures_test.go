@@ -0,0 +1,401 @@
+package gojvm
+
+import (
+	"github.com/timob/gojvm/types"
+	"github.com/timob/gojvm/types/classfile"
+	"testing"
+)
+
+/* Fixture classes assembled with the classfile Writer and defined (in the
+system class loader) as the shared JVM starts, rather than compiled with javac;
+
+Verifies:
+	The assembled fixtures are found by name, from any thread
+*/
+
+// the fixtures, by name
+var fixtures = map[string]func(name string) []byte{
+	TrivialClass:            trivialBytes,
+	PathosClass:             pathosBytes,
+	NativeClass:             nativeBytes,
+	BoxingClass:             boxingBytes,
+	CharsClass:              charsBytes,
+	CleanerClass.AsPath():   cleanerBytes,
+	CleanableClass.AsPath(): cleanableBytes,
+	HandlesClass:            handlesBytes,
+	NullsClass:              nullsBytes,
+	StaticsClass:            staticsBytes,
+}
+
+func defineFixtures(t *testing.T, env *Environment) {
+	for name, assemble := range fixtures {
+		class, err := env.DefineClass(types.NewName(name), nil, assemble(name))
+		fatalIf(t, err != nil, "Couldn't define fixture %s: %v", name, err)
+		env.DeleteGlobalClassRef(class)
+	}
+}
+
+// appends op and its two byte operand to code
+func op2(code []byte, op byte, i uint16) []byte {
+	return append(code, op, byte(i>>8), byte(i))
+}
+
+/*
+assembles:
+
+	class Trivial {
+		String ConstructorUsed;
+		Trivial(){ ConstructorUsed = "()V"; }
+		Trivial(int i){ ConstructorUsed = "(I)V"; }
+		Trivial(long i){ ConstructorUsed = "(J)V"; }
+		Trivial(String i){ ConstructorUsed = "(Ljava/lang/String;)V"; }
+		String getConstructorUsed(){ return this.ConstructorUsed; }
+	}
+*/
+func trivialBytes(name string) []byte {
+	w := classfile.NewWriter()
+	w.Field(0, "ConstructorUsed", "Ljava/lang/String;")
+	field := w.FieldRef(name, "ConstructorUsed", "Ljava/lang/String;")
+	for _, desc := range []string{"()V", "(I)V", "(J)V", "(Ljava/lang/String;)V"} {
+		sig, _ := types.ParseMethodSignature(desc)
+		locals := uint16(1)
+		for _, p := range sig.Params {
+			locals++
+			if p.Kind() == types.LongKind {
+				locals++
+			}
+		}
+		code := []byte{0x2a}                                                     // aload_0
+		code = op2(code, 0xb7, w.MethodRef("java/lang/Object", "<init>", "()V")) // invokespecial
+		code = append(code, 0x2a)
+		code = op2(code, 0x13, w.String(desc)) // ldc_w
+		code = op2(code, 0xb5, field)          // putfield
+		code = append(code, 0xb1)              // return
+		w.Method(0, "<init>", desc, 2, locals, code)
+	}
+	code := op2([]byte{0x2a}, 0xb4, field)                                              // aload_0, getfield
+	w.Method(0, "getConstructorUsed", "()Ljava/lang/String;", 1, 1, append(code, 0xb0)) // areturn
+	return w.Bytes(classfile.AccSuper, name, "java/lang/Object")
+}
+
+/*
+assembles:
+
+	class Pathos {
+		Pathos() throws Exception { throw new Exception("Mwahahahahaa"); }
+	}
+*/
+func pathosBytes(name string) []byte {
+	w := classfile.NewWriter()
+	code := op2([]byte{0x2a}, 0xb7, w.MethodRef("java/lang/Object", "<init>", "()V")) // aload_0, invokespecial
+	code = op2(code, 0xbb, w.Class("java/lang/Exception"))                            // new
+	code = append(code, 0x59)                                                         // dup
+	code = op2(code, 0x13, w.String("Mwahahahahaa"))                                  // ldc_w
+	code = op2(code, 0xb7, w.MethodRef("java/lang/Exception", "<init>", "(Ljava/lang/String;)V"))
+	code = append(code, 0xbf) // athrow
+	w.Method(0, "<init>", "()V", 3, 1, code, w.Exceptions("java/lang/Exception"))
+	return w.Bytes(classfile.AccSuper, name, "java/lang/Object")
+}
+
+/*
+assembles:
+
+	class Native {
+		public native void NativePing();
+		public native int NativeInt();
+		public native void NativeComplex(Object a, Object b, int i);
+		public native boolean NativeBool();
+		public native short NativeShort();
+		public native long NativeLong();
+		public native float NativeFloat();
+		public native double NativeDouble();
+		public native String NativeString();
+		public static native double NativeSum(int i, double d, long l, float f, String s);
+	}
+*/
+func nativeBytes(name string) []byte {
+	w := classfile.NewWriter()
+	w.DefaultConstructor("java/lang/Object")
+	for _, m := range [][2]string{
+		{"NativePing", "()V"},
+		{"NativeInt", "()I"},
+		{"NativeComplex", "(Ljava/lang/Object;Ljava/lang/Object;I)V"},
+		{"NativeBool", "()Z"},
+		{"NativeShort", "()S"},
+		{"NativeLong", "()J"},
+		{"NativeFloat", "()F"},
+		{"NativeDouble", "()D"},
+		{"NativeString", "()Ljava/lang/String;"},
+	} {
+		w.NativeMethod(classfile.AccPublic|classfile.AccNative, m[0], m[1])
+	}
+	w.NativeMethod(classfile.AccPublic|classfile.AccStatic|classfile.AccNative, "NativeSum", "(IDJFLjava/lang/String;)D")
+	return w.Bytes(classfile.AccSuper, name, "java/lang/Object")
+}
+
+// the code of a method returning field (of desc) of this, with ret
+func getterCode(w *classfile.Writer, class, field, desc string, ret byte) []byte {
+	return append(op2([]byte{0x2a}, 0xb4, w.FieldRef(class, field, desc)), ret) // aload_0, getfield
+}
+
+// the code of a constructor calling super's ()V, followed by body
+func ctorCode(w *classfile.Writer, super string, body ...byte) []byte {
+	code := op2([]byte{0x2a}, 0xb7, w.MethodRef(super, "<init>", "()V")) // aload_0, invokespecial
+	return append(append(code, body...), 0xb1)                           // return
+}
+
+// load, then isNull if it left null (else notNull);  both end in a return
+func ifNullCode(load, isNull, notNull []byte) []byte {
+	code := op2(append([]byte{}, load...), 0xc7, uint16(3+len(isNull))) // ifnonnull
+	return append(append(code, isNull...), notNull...)
+}
+
+/*
+assembles:
+
+	class Boxing {
+		Integer BoxedInt(){ return Integer.valueOf(42); }
+		Object BoxedObject(){ return Long.valueOf(7); }
+		int AddBoxed(Integer a, Integer b){ return a + b; }
+		String Describe(Object o){ return o.getClass().getName(); }
+		double Half(Number n){ return n.doubleValue() / 2; }
+	}
+*/
+func boxingBytes(name string) []byte {
+	w := classfile.NewWriter()
+	w.DefaultConstructor("java/lang/Object")
+	code := op2([]byte{0x10, 42}, 0xb8, w.MethodRef("java/lang/Integer", "valueOf", "(I)Ljava/lang/Integer;"))            // bipush, invokestatic
+	w.Method(0, "BoxedInt", "()Ljava/lang/Integer;", 1, 1, append(code, 0xb0))                                            // areturn
+	code = op2(op2(nil, 0x14, w.Number(int64(7))), 0xb8, w.MethodRef("java/lang/Long", "valueOf", "(J)Ljava/lang/Long;")) // ldc2_w
+	w.Method(0, "BoxedObject", "()Ljava/lang/Object;", 2, 1, append(code, 0xb0))
+	intValue := w.MethodRef("java/lang/Integer", "intValue", "()I")
+	code = op2([]byte{0x2b}, 0xb6, intValue)                                                             // aload_1, invokevirtual
+	code = op2(append(code, 0x2c), 0xb6, intValue)                                                       // aload_2
+	w.Method(0, "AddBoxed", "(Ljava/lang/Integer;Ljava/lang/Integer;)I", 2, 3, append(code, 0x60, 0xac)) // iadd, ireturn
+	code = op2([]byte{0x2b}, 0xb6, w.MethodRef("java/lang/Object", "getClass", "()Ljava/lang/Class;"))
+	code = op2(code, 0xb6, w.MethodRef("java/lang/Class", "getName", "()Ljava/lang/String;"))
+	w.Method(0, "Describe", "(Ljava/lang/Object;)Ljava/lang/String;", 1, 2, append(code, 0xb0))
+	code = op2([]byte{0x2b}, 0xb6, w.MethodRef("java/lang/Number", "doubleValue", "()D"))
+	code = op2(code, 0x14, w.Number(float64(2)))
+	w.Method(0, "Half", "(Ljava/lang/Number;)D", 4, 2, append(code, 0x6f, 0xaf)) // ddiv, dreturn
+	return w.Bytes(classfile.AccSuper, name, "java/lang/Object")
+}
+
+/*
+assembles:
+
+	class Chars {
+		static byte StaticB = 5;
+		static char StaticC = 'j';
+		byte B = -7;
+		char C = 'x';
+		byte[] Bytes = {1, -2, 3};
+		char[] Letters = {'g', 'o'};
+		byte[] NoBytes;
+		byte GetB(){ return B; }
+		char GetC(){ return C; }
+		byte[] Echo(byte[] b){ return b; }
+		char[] Echo(char[] c){ return c; }
+		char Next(char c){ return (char)(c + 1); }
+		char[] NoChars(){ return null; }
+	}
+*/
+func charsBytes(name string) []byte {
+	w := classfile.NewWriter()
+	for _, f := range [][2]string{{"B", "B"}, {"C", "C"}, {"Bytes", "[B"}, {"Letters", "[C"}, {"NoBytes", "[B"}} {
+		w.Field(0, f[0], f[1])
+	}
+	w.Field(classfile.AccStatic, "StaticB", "B")
+	w.Field(classfile.AccStatic, "StaticC", "C")
+	code := op2([]byte{0x08}, 0xb3, w.FieldRef(name, "StaticB", "B")) // iconst_5, putstatic
+	code = op2(append(code, 0x10, 'j'), 0xb3, w.FieldRef(name, "StaticC", "C"))
+	w.Method(classfile.AccStatic, "<clinit>", "()V", 1, 0, append(code, 0xb1))
+
+	body := op2([]byte{0x2a, 0x10, 0xf9}, 0xb5, w.FieldRef(name, "B", "B")) // aload_0, bipush -7, putfield
+	body = op2(append(body, 0x2a, 0x10, 'x'), 0xb5, w.FieldRef(name, "C", "C"))
+	body = append(body, 0x2a, 0x06, 0xbc, 8) // aload_0, iconst_3, newarray byte
+	for i, b := range []byte{1, 0xfe, 3} {
+		body = append(body, 0x59, 0x03+byte(i), 0x10, b, 0x54) // dup, iconst_<i>, bipush, bastore
+	}
+	body = op2(body, 0xb5, w.FieldRef(name, "Bytes", "[B"))
+	body = append(body, 0x2a, 0x05, 0xbc, 5) // aload_0, iconst_2, newarray char
+	for i, c := range []byte{'g', 'o'} {
+		body = append(body, 0x59, 0x03+byte(i), 0x10, c, 0x55) // castore
+	}
+	body = op2(body, 0xb5, w.FieldRef(name, "Letters", "[C"))
+	w.Method(0, "<init>", "()V", 5, 1, ctorCode(w, "java/lang/Object", body...))
+
+	w.Method(0, "GetB", "()B", 1, 1, getterCode(w, name, "B", "B", 0xac)) // ireturn
+	w.Method(0, "GetC", "()C", 1, 1, getterCode(w, name, "C", "C", 0xac))
+	w.Method(0, "Echo", "([B)[B", 1, 2, []byte{0x2b, 0xb0}) // aload_1, areturn
+	w.Method(0, "Echo", "([C)[C", 1, 2, []byte{0x2b, 0xb0})
+	w.Method(0, "Next", "(C)C", 2, 2, []byte{0x1b, 0x04, 0x60, 0x92, 0xac}) // iload_1, iconst_1, iadd, i2c, ireturn
+	w.Method(0, "NoChars", "()[C", 1, 1, []byte{0x01, 0xb0})                // aconst_null, areturn
+	return w.Bytes(classfile.AccSuper, name, "java/lang/Object")
+}
+
+/*
+assembles:
+
+	class Cleaner {
+		int deadKids = 0;
+		void deadKid(Cleaner$Cleanable kid){ deadKids++; }
+		int getDeadKids(){ return deadKids; }
+		Cleaner$Cleanable NewChild(){ return new Cleaner$Cleanable(this); }
+	}
+*/
+func cleanerBytes(name string) []byte {
+	w := classfile.NewWriter()
+	kid := CleanableClass.AsPath()
+	w.Field(0, "deadKids", "I")
+	field := w.FieldRef(name, "deadKids", "I")
+	w.Method(0, "<init>", "()V", 2, 1, ctorCode(w, "java/lang/Object", op2([]byte{0x2a, 0x03}, 0xb5, field)...)) // aload_0, iconst_0, putfield
+	code := op2([]byte{0x2a, 0x59}, 0xb4, field)                                                                 // aload_0, dup, getfield
+	code = op2(append(code, 0x04, 0x60), 0xb5, field)                                                            // iconst_1, iadd, putfield
+	w.Method(0, "deadKid", "(L"+kid+";)V", 3, 2, append(code, 0xb1))
+	w.Method(0, "getDeadKids", "()I", 1, 1, getterCode(w, name, "deadKids", "I", 0xac))
+	code = op2(nil, 0xbb, w.Class(kid))                                                     // new
+	code = op2(append(code, 0x59, 0x2a), 0xb7, w.MethodRef(kid, "<init>", "(L"+name+";)V")) // dup, aload_0, invokespecial
+	w.Method(0, "NewChild", "()L"+kid+";", 3, 1, append(code, 0xb0))
+	return w.Bytes(classfile.AccSuper, name, "java/lang/Object")
+}
+
+/*
+assembles:
+
+	class Cleaner$Cleanable {
+		Cleaner parent;
+		Cleaner$Cleanable(Cleaner daddy){ parent = daddy; }
+		protected void finalize() throws Throwable { parent.deadKid(this); }
+	}
+*/
+func cleanableBytes(name string) []byte {
+	w := classfile.NewWriter()
+	parent := CleanerClass.AsPath()
+	w.Field(0, "parent", "L"+parent+";")
+	field := w.FieldRef(name, "parent", "L"+parent+";")
+	w.Method(0, "<init>", "(L"+parent+";)V", 2, 2, ctorCode(w, "java/lang/Object", op2([]byte{0x2a, 0x2b}, 0xb5, field)...)) // aload_0, aload_1, putfield
+	code := op2([]byte{0x2a}, 0xb4, field)
+	code = op2(append(code, 0x2a), 0xb6, w.MethodRef(parent, "deadKid", "(L"+name+";)V"))
+	w.Method(classfile.AccProtected, "finalize", "()V", 2, 1, append(code, 0xb1), w.Exceptions("java/lang/Throwable"))
+	return w.Bytes(classfile.AccSuper, name, "java/lang/Object")
+}
+
+/*
+assembles:
+
+	class Handles {
+		static int Count;
+		long Total;
+		Integer Boxed;
+		String Label;
+		Handles(String label){ Label = label; }
+		long Add(long n){ Total += n; return Total; }
+		double Scale(double d){ return Total * d; }
+		String Name(){ return Label; }
+		static int Bump(){ return ++Count; }
+	}
+*/
+func handlesBytes(name string) []byte {
+	w := classfile.NewWriter()
+	w.Field(classfile.AccStatic, "Count", "I")
+	w.Field(0, "Total", "J")
+	w.Field(0, "Boxed", "Ljava/lang/Integer;")
+	w.Field(0, "Label", "Ljava/lang/String;")
+	total := w.FieldRef(name, "Total", "J")
+	label := op2([]byte{0x2a, 0x2b}, 0xb5, w.FieldRef(name, "Label", "Ljava/lang/String;")) // aload_0, aload_1, putfield
+	w.Method(0, "<init>", "(Ljava/lang/String;)V", 2, 2, ctorCode(w, "java/lang/Object", label...))
+	code := op2([]byte{0x2a, 0x59}, 0xb4, total)      // aload_0, dup, getfield
+	code = op2(append(code, 0x1f, 0x61), 0xb5, total) // lload_1, ladd, putfield
+	code = op2(append(code, 0x2a), 0xb4, total)
+	w.Method(0, "Add", "(J)J", 5, 3, append(code, 0xad)) // lreturn
+	code = op2([]byte{0x2a}, 0xb4, total)
+	w.Method(0, "Scale", "(D)D", 4, 3, append(code, 0x8a, 0x27, 0x6b, 0xaf)) // l2d, dload_1, dmul, dreturn
+	w.Method(0, "Name", "()Ljava/lang/String;", 1, 1, getterCode(w, name, "Label", "Ljava/lang/String;", 0xb0))
+	count := w.FieldRef(name, "Count", "I")
+	code = op2(nil, 0xb2, count)                            // getstatic
+	code = op2(append(code, 0x04, 0x60, 0x59), 0xb3, count) // iconst_1, iadd, dup, putstatic
+	w.Method(classfile.AccStatic, "Bump", "()I", 2, 0, append(code, 0xac))
+	return w.Bytes(classfile.AccSuper, name, "java/lang/Object")
+}
+
+/*
+assembles:
+
+	class Nulls {
+		String Which(String s){ return s == null ? "String:null" : "String"; }
+		String Which(Integer i){ return i == null ? "Integer:null" : "Integer"; }
+		boolean IsNull(Object o){ return o == null; }
+		int Length(String s){ return s == null ? -1 : s.length(); }
+		int Count(int[] a){ return a == null ? -1 : a.length; }
+	}
+*/
+func nullsBytes(name string) []byte {
+	w := classfile.NewWriter()
+	w.DefaultConstructor("java/lang/Object")
+	for _, class := range []string{"String", "Integer"} {
+		isNull := append(op2(nil, 0x13, w.String(class+":null")), 0xb0) // ldc_w, areturn
+		notNull := append(op2(nil, 0x13, w.String(class)), 0xb0)
+		w.Method(0, "Which", "(Ljava/lang/"+class+";)Ljava/lang/String;", 1, 2, ifNullCode([]byte{0x2b}, isNull, notNull)) // aload_1
+	}
+	w.Method(0, "IsNull", "(Ljava/lang/Object;)Z", 1, 2, ifNullCode([]byte{0x2b}, []byte{0x04, 0xac}, []byte{0x03, 0xac})) // iconst_1/0, ireturn
+	length := append(op2([]byte{0x2b}, 0xb6, w.MethodRef("java/lang/String", "length", "()I")), 0xac)
+	w.Method(0, "Length", "(Ljava/lang/String;)I", 1, 2, ifNullCode([]byte{0x2b}, []byte{0x02, 0xac}, length))  // iconst_m1
+	w.Method(0, "Count", "([I)I", 1, 2, ifNullCode([]byte{0x2b}, []byte{0x02, 0xac}, []byte{0x2b, 0xbe, 0xac})) // arraylength
+	return w.Bytes(classfile.AccSuper, name, "java/lang/Object")
+}
+
+/*
+assembles:
+
+	class Statics {
+		static boolean Z;
+		static byte B;
+		static char C;
+		static short S;
+		static int I;
+		static long J;
+		static float F;
+		static double D;
+		static String Str;
+		static int[] Ints;
+		static String[] Strs;
+		static int IntsLength(){ return Ints == null ? -1 : Ints.length; }
+		static String LastStr(){ return Strs[Strs.length - 1]; }
+	}
+*/
+func staticsBytes(name string) []byte {
+	w := classfile.NewWriter()
+	w.DefaultConstructor("java/lang/Object")
+	for _, f := range [][2]string{
+		{"Z", "Z"}, {"B", "B"}, {"C", "C"}, {"S", "S"}, {"I", "I"}, {"J", "J"}, {"F", "F"}, {"D", "D"},
+		{"Str", "Ljava/lang/String;"}, {"Ints", "[I"}, {"Strs", "[Ljava/lang/String;"},
+	} {
+		w.Field(classfile.AccStatic, f[0], f[1])
+	}
+	ints := op2(nil, 0xb2, w.FieldRef(name, "Ints", "[I")) // getstatic
+	w.Method(classfile.AccStatic, "IntsLength", "()I", 1, 0, ifNullCode(ints, []byte{0x02, 0xac}, append(ints, 0xbe, 0xac)))
+	strs := op2(nil, 0xb2, w.FieldRef(name, "Strs", "[Ljava/lang/String;"))
+	code := append(append(strs, strs...), 0xbe, 0x04, 0x64, 0x32, 0xb0) // arraylength, iconst_1, isub, aaload, areturn
+	w.Method(classfile.AccStatic, "LastStr", "()Ljava/lang/String;", 3, 0, code)
+	return w.Bytes(classfile.AccSuper, name, "java/lang/Object")
+}
+
+func TestJVMFixtures(t *testing.T) {
+	setupJVM(t)
+	done := make(chan error)
+	go func() {
+		env, err := _jvm.AttachCurrentThread()
+		if err == nil {
+			for name := range fixtures {
+				if _, err = env.GetClassStr(name); err != nil {
+					break
+				}
+			}
+			_jvm.DetachCurrentThread()
+		}
+		done <- err
+	}()
+	err := <-done
+	fatalIf(t, err != nil, "Couldn't find the fixtures from another thread: %v", err)
+}
@@ -0,0 +1,130 @@
+package gojvm
+
+import (
+	"github.com/timob/gojvm/types"
+	"testing"
+)
+
+var HandlesClass = "org/golang/ext/gojvm/testing/Handles"
+/* Instance and static members, for resolving once and reusing;
+
+Verifies:
+	Class.Method handles (instance, static & constructor) with converted parameters
+	Class.Field handles Get/Set, including wrapper fields
+	Handles hold their own class ref, outliving the thread that resolved them
+*/
+
+func TestJVMMethodHandles(t *testing.T) {
+	env := setupJVM(t)
+	klass, err := env.GetClassStr(HandlesClass)
+	fatalIf(t, err != nil, "Couldn't get Handles: %v", err)
+
+	ctor, err := klass.Method(env, "<init>", types.MethodSignature{
+		Params: []types.Typed{types.Class{types.JavaLangString}},
+	})
+	fatalIf(t, err != nil, "Couldn't resolve constructor: %v", err)
+	obj, err := ctor.New(env, "handled")
+	fatalIf(t, err != nil, "Couldn't construct Handles: %v", err)
+
+	add, err := klass.Method(env, "Add", types.MethodSignature{
+		Params: []types.Typed{types.Basic(types.LongKind)},
+		Return: types.Basic(types.LongKind),
+	})
+	fatalIf(t, err != nil, "Couldn't resolve Add: %v", err)
+	fatalIf(t, add.Static, "Add resolved as static")
+	var total int64
+	for i := 1; i <= 10; i++ {
+		// an int, passed for the declared long
+		total, err = add.InvokeLong(env, obj, i)
+		fatalIf(t, err != nil, "Couldn't invoke Add(%d): %v", i, err)
+	}
+	fatalInEq(t, int64(55), total, "Wrong total")
+
+	_, err = add.InvokeInt(env, obj, 1)
+	fatalIf(t, err != ErrWrongKind, "InvokeInt on a long method should fail (got %v)", err)
+	_, err = add.InvokeLong(env, obj)
+	fatalIf(t, err != ErrArgCount, "Missing argument should fail (got %v)", err)
+	_, err = add.InvokeLong(env, nil, 1)
+	fatalIf(t, err != ErrNullReceiver, "Null receiver should fail (got %v)", err)
+
+	scale, err := klass.Method(env, "Scale", types.MethodSignature{
+		Params: []types.Typed{types.Basic(types.DoubleKind)},
+		Return: types.Basic(types.DoubleKind),
+	})
+	fatalIf(t, err != nil, "Couldn't resolve Scale: %v", err)
+	v, err := scale.Invoke(env, obj, float32(.5))
+	fatalIf(t, err != nil, "Couldn't invoke Scale: %v", err)
+	fatalInEq(t, 27.5, v, "Wrong Scale")
+
+	name, err := klass.Method(env, "Name", types.MethodSignature{Return: types.Class{types.JavaLangString}})
+	fatalIf(t, err != nil, "Couldn't resolve Name: %v", err)
+	sobj, err := name.InvokeObj(env, obj)
+	fatalIf(t, err != nil, "Couldn't invoke Name: %v", err)
+	s, _, err := env.ToString(sobj)
+	fatalIf(t, err != nil || s != "handled", "Wrong Name (%q, %v)", s, err)
+
+	bump, err := klass.Method(env, "Bump", types.MethodSignature{Return: types.Basic(types.IntKind)})
+	fatalIf(t, err != nil, "Couldn't resolve Bump: %v", err)
+	fatalIf(t, !bump.Static, "Bump not resolved as static")
+	first, err := bump.InvokeInt(env, nil)
+	fatalIf(t, err != nil, "Couldn't invoke Bump: %v", err)
+	second, err := bump.InvokeInt(env, nil)
+	fatalIf(t, err != nil, "Couldn't invoke Bump: %v", err)
+	fatalInEq(t, first+1, second, "Bump didn't count")
+}
+
+func TestJVMFieldHandles(t *testing.T) {
+	env := setupJVM(t)
+	klass, err := env.GetClassStr(HandlesClass)
+	fatalIf(t, err != nil, "Couldn't get Handles: %v", err)
+	obj, err := env.NewInstance(klass, "fields")
+	fatalIf(t, err != nil, "Couldn't construct Handles: %v", err)
+
+	total, err := klass.Field(env, "Total", types.Basic(types.LongKind))
+	fatalIf(t, err != nil, "Couldn't resolve Total: %v", err)
+	err = total.Set(env, obj, 1<<20)
+	fatalIf(t, err != nil, "Couldn't set Total: %v", err)
+	v, err := total.Get(env, obj)
+	fatalIf(t, err != nil, "Couldn't get Total: %v", err)
+	fatalInEq(t, int64(1<<20), v, "Wrong Total")
+	fatalIf(t, total.Set(env, obj, 1.5) != ErrWrongKind, "Setting a float into a long should fail")
+
+	boxed, err := klass.Field(env, "Boxed", types.Class{types.JavaLangInteger})
+	fatalIf(t, err != nil, "Couldn't resolve Boxed: %v", err)
+	v, err = boxed.Get(env, obj)
+	fatalIf(t, err != nil || v != nil, "Boxed should start null (%v, %v)", v, err)
+	err = boxed.Set(env, obj, 9)
+	fatalIf(t, err != nil, "Couldn't set Boxed: %v", err)
+	v, err = boxed.Get(env, obj)
+	fatalIf(t, err != nil, "Couldn't get Boxed: %v", err)
+	fatalInEq(t, 9, v, "Wrong Boxed")
+
+	count, err := klass.Field(env, "Count", types.Basic(types.IntKind))
+	fatalIf(t, err != nil, "Couldn't resolve Count: %v", err)
+	fatalIf(t, !count.Static, "Count not resolved as static")
+	fatalIf(t, count.Set(env, nil, int16(-3)) != nil, "Couldn't set Count")
+	v, err = count.Get(env, nil)
+	fatalIf(t, err != nil, "Couldn't get Count: %v", err)
+	fatalInEq(t, -3, v, "Wrong Count")
+}
+
+func TestJVMHandleOutlivesThread(t *testing.T) {
+	env := setupJVM(t)
+	done := make(chan *MethodHandle)
+	go func() {
+		var h *MethodHandle
+		if tenv, err := _jvm.AttachCurrentThread(); err == nil {
+			if klass, err := tenv.GetClassStr(HandlesClass); err == nil {
+				h, _ = klass.Method(tenv, "Bump", types.MethodSignature{Return: types.Basic(types.IntKind)})
+			}
+			// releases the thread's cached Handles class
+			_jvm.DetachCurrentThread()
+		}
+		done <- h
+	}()
+	bump := <-done
+	fatalIf(t, bump == nil, "Couldn't resolve Bump on another thread")
+	defer bump.Release(env)
+	_, err := bump.InvokeInt(env, nil)
+	fatalIf(t, err != nil, "Couldn't invoke Bump after its thread detached: %v", err)
+}
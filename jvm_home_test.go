@@ -0,0 +1,56 @@
+package gojvm
+
+import (
+	"os"
+	"testing"
+)
+
+/* A home loader over a class directory that is not on the class path
+(see classDir in jvm_classloader_test.go);
+
+Verifies:
+	Attached threads resolve classes through the home loader
+	Attached threads have it as their context class loader
+*/
+
+func TestJVMHomeLoader(t *testing.T) {
+	env := setupJVM(t)
+	name := "org/golang/ext/gojvm/testing/AtHome"
+	dir := classDir(t, name)
+	defer os.RemoveAll(dir)
+
+	loader, err := env.NewClassLoader(dir)
+	fatalIf(t, err != nil, "Couldn't make class loader: %v", err)
+	defer env.DeleteGlobalRef(loader)
+	err = _jvm.SetHomeLoader(env, loader)
+	fatalIf(t, err != nil, "Couldn't set home loader: %v", err)
+	defer _jvm.SetHomeLoader(env, nil)
+
+	type result struct {
+		found, context bool
+		err            error
+	}
+	done := make(chan result)
+	go func() {
+		var r result
+		defer func() { done <- r }()
+		tenv, err := _jvm.AttachCurrentThread()
+		if err != nil {
+			r.err = err
+			return
+		}
+		defer _jvm.DetachCurrentThread()
+		_, err = tenv.GetClassStr(name)
+		r.found = err == nil
+		ctx, err := tenv.contextClassLoader()
+		if err != nil {
+			r.err = err
+			return
+		}
+		r.context = tenv.IsSameObject(ctx, loader)
+	}()
+	r := <-done
+	fatalIf(t, r.err != nil, "Attached thread failed: %v", r.err)
+	fatalIf(t, !r.found, "Attached thread couldn't find %s through the home loader", name)
+	fatalIf(t, !r.context, "Attached thread's context loader isn't the home loader")
+}
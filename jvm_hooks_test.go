@@ -0,0 +1,40 @@
+package gojvm
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+/* The JVM's exit hook;  as System.exit ends the process, it runs in a child
+test process (GOJVM_EXIT set);
+
+Verifies:
+	JvmConfig.OnExit
+*/
+
+// System.exit in a child JVM with an OnExit hook (GOJVM_EXIT set) reaches Go before the process exits
+func TestJVMOnExit(t *testing.T) {
+	if os.Getenv("GOJVM_EXIT") == "" {
+		cmd := exec.Command(os.Args[0], "-test.run=TestJVMOnExit")
+		cmd.Env = append(os.Environ(), "GOJVM_EXIT=1")
+		out, err := cmd.CombinedOutput()
+		exit, ok := err.(*exec.ExitError)
+		fatalIf(t, !ok || exit.ExitCode() != 3, "Child should exit with 3 (got %v)\n%s", err, out)
+		fatalIf(t, !strings.Contains(string(out), "OnExit(3)"), "OnExit wasn't called:\n%s", out)
+		return
+	}
+	_, env, err := NewJVM(0, JvmConfig{
+		ClassPath: []string{"../../../java/", DefaultJREPath},
+		OnExit: func(code int) {
+			fmt.Printf("OnExit(%d)\n", code)
+		},
+	})
+	fatalIf(t, err != nil, "Error initializing JVM: %v", err)
+	system, err := env.GetClassStr(SystemClass)
+	fatalIf(t, err != nil, "Couldn't load System: %v", err)
+	system.CallVoid(env, true, "exit", 3)
+	t.Fatalf("System.exit returned")
+}
@@ -0,0 +1,76 @@
+package gojvm
+
+import (
+	"strings"
+	"testing"
+)
+
+/* Go values behind java/lang/Runnable and java/util/Comparator proxies;
+
+Verifies:
+	Implement matches Java methods to Go methods by name and signature
+	Arguments reach Go as string, results return boxed
+	Object's methods (toString) for methods the Go value lacks
+	Go methods of a Java method's name that aren't callbacks are ignored
+	Unimplement releases the Go value (and calls then throw)
+*/
+
+type goRunnable struct {
+	runs int
+}
+
+func (self *goRunnable) Run(env *Environment, proxy *Object) {
+	self.runs++
+}
+
+type byLength struct{}
+
+func (self byLength) Compare(env *Environment, proxy *Object, a, b string) int {
+	return len(a) - len(b)
+}
+
+// not a callback;  Comparator.equals stays Object's
+func (self byLength) Equals(other byLength) bool {
+	return true
+}
+
+func TestJVMImplementRunnable(t *testing.T) {
+	env := setupJVM(t)
+	r := &goRunnable{}
+	proxy, err := env.Implement("java/lang/Runnable", r)
+	fatalIf(t, err != nil, "Couldn't implement Runnable: %v", err)
+	defer env.DeleteGlobalRef(proxy)
+	for i := 0; i < 3; i++ {
+		err = proxy.CallVoid(env, false, "run")
+		fatalIf(t, err != nil, "[%d] Couldn't run: %v", i, err)
+	}
+	fatalInEq(t, 3, r.runs, "Wrong run count")
+
+	s, _, err := proxy.CallString(env, false, "toString")
+	fatalIf(t, err != nil, "Couldn't call toString: %v", err)
+	fatalIf(t, !strings.Contains(s, "goRunnable"), "Wrong proxy toString: %s", s)
+
+	err = env.Unimplement(proxy)
+	fatalIf(t, err != nil, "Couldn't unimplement: %v", err)
+	defer defMute(env)()
+	err = proxy.CallVoid(env, false, "run")
+	fatalIf(t, err == nil, "Calling a released implementation should throw")
+}
+
+func TestJVMImplementComparator(t *testing.T) {
+	env := setupJVM(t)
+	proxy, err := env.Implement("java/util/Comparator", byLength{})
+	fatalIf(t, err != nil, "Couldn't implement Comparator: %v", err)
+	defer env.DeleteGlobalRef(proxy)
+	defer env.Unimplement(proxy)
+	a, err := env.NewStringObject("four")
+	fatalIf(t, err != nil, "Couldn't make string: %v", err)
+	b, err := env.NewStringObject("sixteen")
+	fatalIf(t, err != nil, "Couldn't make string: %v", err)
+	c, err := proxy.CallInt(env, false, "compare", a, b)
+	fatalIf(t, err != nil, "Couldn't compare: %v", err)
+	fatalInEq(t, -3, c, "Wrong comparison")
+
+	_, err = env.Implement("java/lang/Runnable", byLength{})
+	fatalIf(t, err != ErrNoImplementation, "A value without run shouldn't implement Runnable (got %v)", err)
+}
@@ -1,7 +1,6 @@
 package gojvm
 
-//#cgo LDFLAGS:-ljvm	-L/usr/lib/jvm/java-6-sun/jre/lib/amd64/server/
-//#include</usr/lib/jvm/default-java/include/jni.h>
+//#include<jni.h>
 //#include <stdlib.h>
 //#include <unistd.h>
 //#include "helpers.h"
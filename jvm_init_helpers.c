@@ -1,27 +1,140 @@
+#define _GNU_SOURCE
 #include "helpers.h"
+#include "_cgo_export.h"
+#include <dlfcn.h>
+#include <stdio.h>
+#include <stdarg.h>
 
 /* string is duplicated into args, and may be freed after calling, 0 on success. */
 int addStringArgument(JavaVMInitArgs *args, const char *string){
 	if (args == NULL ){
 		return -1;
 	}
-	int opti = args->nOptions++;
-	args->options = realloc(args->options, sizeof(JavaVMInitArgs) * args->nOptions);
-	if (args -> options == NULL ) {
+	char *dup = strdup(string);
+	if (dup == NULL) {
 		return -1;
 	}
-	args->options[opti].optionString = strdup(string);
-	args->options[opti].extraInfo = NULL;
-	if (args->options[opti].optionString == NULL ){
+	JavaVMOption *options = realloc(args->options, sizeof(JavaVMOption) * (args->nOptions + 1));
+	if (options == NULL) {
+		free(dup);
 		return -1;
 	}
+	args->options = options;
+	args->options[args->nOptions].optionString = dup;
+	args->options[args->nOptions].extraInfo = NULL;
+	args->nOptions++;
 	return 0;
 }
 
+/* frees the options added by addStringArgument (the JVM copies what it keeps) */
+void freeJVMArgs(JavaVMInitArgs *args){
+	for (int i = 0; i < args->nOptions; i++) {
+		free(args->options[i].optionString);
+	}
+	free(args->options);
+	args->options = NULL;
+	args->nOptions = 0;
+}
+
+
+/* The JVM's vfprintf, exit and abort hooks;  each forwards to Go (see JvmConfig.OnLog). */
+static jint JNICALL vmLogHook(FILE *fp, const char *format, va_list args){
+	char buf[512];
+	va_list again;
+	va_copy(again, args);
+	int n = vsnprintf(buf, sizeof(buf), format, args);
+	if (n >= (int)sizeof(buf)) {
+		char *long_buf = malloc(n + 1);
+		if (long_buf != NULL) {
+			vsnprintf(long_buf, n + 1, format, again);
+			goVMLog(long_buf);
+			free(long_buf);
+			va_end(again);
+			return n;
+		}
+	}
+	va_end(again);
+	if (n >= 0) {
+		goVMLog(buf);
+	}
+	return n;
+}
+
+static void JNICALL vmExitHook(jint code){
+	goVMExit(code);
+}
+
+static void JNICALL vmAbortHook(void){
+	goVMAbort();
+}
+
+/* adds the "vfprintf", "exit" or "abort" option, with its hook as extraInfo;  0 on success. */
+int addHookArgument(JavaVMInitArgs *args, const char *hook){
+	void *fn = NULL;
+	if (strcmp(hook, "vfprintf") == 0) {
+		fn = (void *)vmLogHook;
+	} else if (strcmp(hook, "exit") == 0) {
+		fn = (void *)vmExitHook;
+	} else if (strcmp(hook, "abort") == 0) {
+		fn = (void *)vmAbortHook;
+	}
+	if (fn == NULL || addStringArgument(args, hook) != 0) {
+		return -1;
+	}
+	args->options[args->nOptions - 1].extraInfo = fn;
+	return 0;
+}
+
+/* libjvm is opened at runtime (see discovery.go), rather than linked;
+   these are its JNI_ entry points once loaded. */
+static void *libjvm = NULL;
+static const char *libjvmErr = NULL;
+static jint (JNICALL *createJavaVM)(JavaVM **, void **, void *) = NULL;
+static jint (JNICALL *getCreatedJavaVMs)(JavaVM **, jsize, jsize *) = NULL;
 
+/* 0 on success (or if already loaded);  on failure, see libJVMError */
+int loadLibJVM(const char *path){
+	if (libjvm != NULL) {
+		return 0;
+	}
+	void *lib = dlopen(path, RTLD_NOW | RTLD_GLOBAL);
+	if (lib == NULL) {
+		libjvmErr = dlerror();
+		return -1;
+	}
+	createJavaVM = dlsym(lib, "JNI_CreateJavaVM");
+	getCreatedJavaVMs = dlsym(lib, "JNI_GetCreatedJavaVMs");
+	if (createJavaVM == NULL || getCreatedJavaVMs == NULL) {
+		libjvmErr = "libjvm lacks the JNI_ invocation functions";
+		dlclose(lib);
+		return -1;
+	}
+	libjvm = lib;
+	return 0;
+}
+
+const char *libJVMError(){
+	return libjvmErr;
+}
+
+/* the JVMs already created in this process;  when libjvm was not loaded by
+   us (e.g., we are a library loaded by java), its symbols are looked up in
+   the process.  -2 if libjvm can't be found at all. */
+jint existingJVMs(JavaVM **vms, jsize n, jsize *found){
+	if (getCreatedJavaVMs == NULL) {
+		getCreatedJavaVMs = dlsym(RTLD_DEFAULT, "JNI_GetCreatedJavaVMs");
+	}
+	if (getCreatedJavaVMs == NULL) {
+		return -2;
+	}
+	return getCreatedJavaVMs(vms, n, found);
+}
 
 jint	newJVMContext(JavaVM **jvm, void *env, JavaVMInitArgs *args){
-	jint out = JNI_CreateJavaVM(jvm, (void **)env, args);
+	if (createJavaVM == NULL) {
+		return JNI_ERR;
+	}
+	jint out = createJavaVM(jvm, (void **)env, args);
 	return out;
 }
 
@@ -33,4 +146,12 @@ jint vmDetachCurrentThread(JavaVM *jvm){
 	return (*jvm)->DetachCurrentThread(jvm);
 }
 
+jint vmGetEnv(JavaVM *jvm, void *env, jint version){
+	return (*jvm)->GetEnv(jvm, (void **)env, version);
+}
+
+jint vmDestroyJavaVM(JavaVM *jvm){
+	return (*jvm)->DestroyJavaVM(jvm);
+}
+
 
@@ -0,0 +1,101 @@
+package gojvm
+
+import (
+	"github.com/timob/gojvm/types"
+	"strings"
+	"testing"
+)
+
+/* Go funcs as java/util/function and java/lang/Runnable objects;
+
+Verifies:
+	Func, BiFunction, Supplier, Predicate, Consumer and Runnable call the Go func
+	Arguments unbox (or convert to string), results box
+	A leading *Environment parameter gets the calling environment
+	Funcs of the wrong form are refused with ErrBadLambda
+*/
+
+func TestJVMLambdaFunctions(t *testing.T) {
+	env := setupJVM(t)
+	object := types.Class{types.JavaLangObject}
+
+	upper, err := env.Func(strings.ToUpper)
+	fatalIf(t, err != nil, "Couldn't make Function: %v", err)
+	defer env.DeleteGlobalRef(upper)
+	defer env.Unimplement(upper)
+	obj, err := upper.CallObj(env, false, "apply", object, "gopher")
+	fatalIf(t, err != nil, "Couldn't apply: %v", err)
+	s, _, err := env.ToString(obj)
+	fatalIf(t, err != nil, "Couldn't convert result: %v", err)
+	fatalInEq(t, "GOPHER", s, "Wrong Function result")
+
+	add, err := env.BiFunction(func(a, b int) int { return a + b })
+	fatalIf(t, err != nil, "Couldn't make BiFunction: %v", err)
+	defer env.DeleteGlobalRef(add)
+	defer env.Unimplement(add)
+	v, err := add.Call(env, false, "apply", object, 3, 4)
+	fatalIf(t, err != nil, "Couldn't apply: %v", err)
+	fatalInEq(t, 7, v, "Wrong BiFunction result")
+
+	n := int64(0)
+	next, err := env.Supplier(func(env *Environment) int64 {
+		fatalIf(t, env == nil, "No environment for the Supplier")
+		n++
+		return n
+	})
+	fatalIf(t, err != nil, "Couldn't make Supplier: %v", err)
+	defer env.DeleteGlobalRef(next)
+	defer env.Unimplement(next)
+	for i := 1; i <= 3; i++ {
+		v, err = next.Call(env, false, "get", object)
+		fatalIf(t, err != nil, "[%d] Couldn't get: %v", i, err)
+		fatalInEq(t, int64(i), v, "Wrong Supplier result")
+	}
+}
+
+func TestJVMLambdaPredicates(t *testing.T) {
+	env := setupJVM(t)
+
+	even, err := env.Predicate(func(n int) bool { return n%2 == 0 })
+	fatalIf(t, err != nil, "Couldn't make Predicate: %v", err)
+	defer env.DeleteGlobalRef(even)
+	defer env.Unimplement(even)
+	for i := 0; i < 4; i++ {
+		b, err := even.CallBool(env, false, "test", i)
+		fatalIf(t, err != nil, "[%d] Couldn't test: %v", i, err)
+		fatalInEq(t, i%2 == 0, b, "Wrong Predicate result")
+	}
+
+	seen := []interface{}{}
+	consumer, err := env.Consumer(func(v interface{}) { seen = append(seen, v) })
+	fatalIf(t, err != nil, "Couldn't make Consumer: %v", err)
+	defer env.DeleteGlobalRef(consumer)
+	defer env.Unimplement(consumer)
+	for _, v := range []interface{}{"one", 2} {
+		err = consumer.CallVoid(env, false, "accept", v)
+		fatalIf(t, err != nil, "Couldn't accept %v: %v", v, err)
+	}
+	fatalInEq(t, 2, len(seen), "Wrong Consumer count")
+	fatalInEq(t, "one", seen[0], "Wrong first Consumer value")
+	fatalInEq(t, 2, seen[1], "Wrong second Consumer value")
+
+	runs := 0
+	runnable, err := env.Runnable(func() { runs++ })
+	fatalIf(t, err != nil, "Couldn't make Runnable: %v", err)
+	defer env.DeleteGlobalRef(runnable)
+	defer env.Unimplement(runnable)
+	err = runnable.CallVoid(env, false, "run")
+	fatalIf(t, err != nil, "Couldn't run: %v", err)
+	fatalInEq(t, 1, runs, "Wrong run count")
+
+	_, err = env.Predicate(func(n int) int { return n })
+	fatalIf(t, err != ErrBadLambda, "A Predicate must return bool (got %v)", err)
+	_, err = env.Func(func(a, b int) int { return a })
+	fatalIf(t, err != ErrBadLambda, "A Function takes one argument (got %v)", err)
+	_, err = env.Runnable(42)
+	fatalIf(t, err != ErrBadLambda, "A Runnable must be a func (got %v)", err)
+	_, err = env.Runnable(nil)
+	fatalIf(t, err != ErrBadLambda, "A nil Runnable should be refused (got %v)", err)
+	_, err = env.Supplier(func() []int { return nil })
+	fatalIf(t, err != ErrBadLambda, "A Supplier can't return a slice (got %v)", err)
+}
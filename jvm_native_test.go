@@ -0,0 +1,155 @@
+package gojvm
+
+import (
+	"github.com/timob/gojvm/types"
+	"testing"
+)
+
+/* Uses the natives of NativeClass (see jvm_xclass_test.go);
+
+Verifies:
+	Go natives of mixed primitive/object parameters, with a declared signature
+	Signature/callback disagreement is refused at registration
+	Re-registering a method, or failing to register one, uses no slot up
+	Panics in Go natives are thrown to Java
+	A Java thread's Environment is only kept for the native call
+	JNI_OnLoad hooks run against the calling VM, and their errors are thrown
+*/
+
+var nativeSumSig = types.MethodSignature{
+	Params: []types.Typed{
+		types.Basic(types.IntKind),
+		types.Basic(types.DoubleKind),
+		types.Basic(types.LongKind),
+		types.Basic(types.FloatKind),
+		types.Class{types.JavaLangString},
+	},
+	Return: types.Basic(types.DoubleKind),
+}
+
+func TestJVMNativeParams(t *testing.T) {
+	env := setupJVM(t)
+	klass, err := env.GetClassStr(NativeClass)
+	fatalIf(t, err != nil, "Couldn't load Native: %v", err)
+	err = env.RegisterNative(NativeClass, "NativeSum", nativeSumSig, func(E *Environment, cls *Object, i int, d float64, l int64, f float32, s string) float64 {
+		return float64(i) + d + float64(l) + float64(f) + float64(len(s))
+	})
+	fatalIf(t, err != nil, "Couldn't register NativeSum: %v", err)
+	sum, err := klass.CallDouble(env, true, "NativeSum", 1, 0.5, int64(1)<<40, float32(.25), "four")
+	fatalIf(t, err != nil, "Couldn't call NativeSum: %v", err)
+	fatalInEq(t, float64(int64(1)<<40)+5.75, sum, "Wrong NativeSum")
+
+	err = env.RegisterNative(NativeClass, "NativeSum", nativeSumSig, func(E *Environment, cls *Object, i int, d float64) float64 {
+		return 0
+	})
+	fatalIf(t, err != ErrArgCount, "Short callback should be refused (got %v)", err)
+	err = env.RegisterNative(NativeClass, "NativeSum", nativeSumSig, func(E *Environment, cls *Object, i int, d float64, l int64, f float32, s string) int {
+		return 0
+	})
+	fatalIf(t, err != ErrWrongKind, "Mistyped callback should be refused (got %v)", err)
+}
+
+func TestJVMNativeSlotReuse(t *testing.T) {
+	env := setupJVM(t)
+	obj, err := env.NewInstanceStr(NativeClass)
+	fatalIf(t, err != nil, "Couldn't instantiate Native: %v", err)
+	defer env.DeleteLocalRef(obj)
+	for i := 0; i < 512; i++ { // twice the slots
+		n := i
+		err = env.RegisterNative(NativeClass, "NativeInt", types.MethodSignature{}, func(E *Environment, O *Object) int {
+			return n
+		})
+		fatalIf(t, err != nil, "Couldn't re-register NativeInt (%d): %v", i, err)
+	}
+	i, err := obj.CallInt(env, false, "NativeInt")
+	fatalIf(t, err != nil, "Couldn't call NativeInt: %v", err)
+	fatalInEq(t, 511, i, "NativeInt should call the last registered")
+
+	defer defMute(env)()
+	for i := 0; i < 512; i++ { // twice the slots
+		err = env.RegisterNative(NativeClass, "NoSuchNative", types.MethodSignature{}, func(E *Environment, O *Object) int {
+			return 0
+		})
+		fatalIf(t, err == nil || err == ErrNativeSlots, "Registering a missing method should fail, and give its slot back (%d: %v)", i, err)
+	}
+}
+
+func TestJVMNativeThreadEnv(t *testing.T) {
+	env := setupJVM(t)
+	before := len(AllEnvs.owned(env.jvm))
+	during := 0
+	run, err := env.Runnable(func(E *Environment) {
+		during = len(AllEnvs.owned(E.jvm))
+	})
+	fatalIf(t, err != nil, "Couldn't make Runnable: %v", err)
+	defer env.DeleteGlobalRef(run)
+	defer env.Unimplement(run)
+	thread, err := env.NewInstanceStr("java/lang/Thread", run)
+	fatalIf(t, err != nil, "Couldn't make Thread: %v", err)
+	defer env.DeleteGlobalRef(thread)
+	fatalIf(t, thread.CallVoid(env, false, "start") != nil, "Couldn't start the thread")
+	fatalIf(t, thread.CallVoid(env, false, "join") != nil, "Couldn't join the thread")
+	fatalInEq(t, before+1, during, "The Java thread had no Environment during the call")
+	fatalInEq(t, before, len(AllEnvs.owned(env.jvm)), "The Java thread's Environment outlived the call")
+}
+
+func TestJVMNativePanic(t *testing.T) {
+	env := setupJVM(t)
+	defer defMute(env)()
+	err := env.RegisterNative(NativeClass, "NativeInt", types.MethodSignature{}, func(E *Environment, O *Object) int {
+		panic("native failure")
+	})
+	fatalIf(t, err != nil, "Couldn't register NativeInt: %v", err)
+	obj, err := env.NewInstanceStr(NativeClass)
+	fatalIf(t, err != nil, "Couldn't instantiate Native: %v", err)
+	defer env.DeleteLocalRef(obj)
+	_, err = obj.CallInt(env, false, "NativeInt")
+	fatalIf(t, err == nil, "A panicking native should throw")
+}
+
+// empties the OnLoad hooks for a test, returning a func restoring them
+func clearLoadHooks() (restore func()) {
+	loadHooks.Lock()
+	saved := loadHooks.onLoad
+	loadHooks.onLoad = nil
+	loadHooks.Unlock()
+	return func() {
+		loadHooks.Lock()
+		loadHooks.onLoad = saved
+		loadHooks.Unlock()
+	}
+}
+
+func TestJVMOnLoad(t *testing.T) {
+	env := setupJVM(t)
+	defer clearLoadHooks()()
+	var loaded *JVM
+	OnLoad(func(jvm *JVM, env *Environment) error {
+		loaded = jvm
+		return env.RegisterNative(NativeClass, "NativeInt", types.MethodSignature{}, func(E *Environment, O *Object) int {
+			return 41
+		})
+	})
+	ver := goJNIOnLoad(env.jvm.jvm, env.env)
+	fatalInEq(t, DEFAULT_JVM_VERSION, int(ver), "Wrong JNI_OnLoad version")
+	fatalIf(t, loaded != env.jvm, "OnLoad hook got another JVM (%v)", loaded)
+
+	obj, err := env.NewInstanceStr(NativeClass)
+	fatalIf(t, err != nil, "Couldn't instantiate Native: %v", err)
+	defer env.DeleteLocalRef(obj)
+	i, err := obj.CallInt(env, false, "NativeInt")
+	fatalIf(t, err != nil, "Couldn't call NativeInt: %v", err)
+	fatalInEq(t, 41, i, "Wrong NativeInt from OnLoad's native")
+}
+
+func TestJVMOnLoadError(t *testing.T) {
+	env := setupJVM(t)
+	defer clearLoadHooks()()
+	OnLoad(func(jvm *JVM, env *Environment) error {
+		return ErrNativeArgs
+	})
+	ver := goJNIOnLoad(env.jvm.jvm, env.env)
+	fatalIf(t, int(ver) == DEFAULT_JVM_VERSION, "A failing hook should fail JNI_OnLoad")
+	defer defMute(env)()
+	fatalIf(t, env.ExceptionOccurred() == nil, "A failing hook should throw an UnsatisfiedLinkError")
+}
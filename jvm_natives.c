@@ -0,0 +1,86 @@
+#include "helpers.h"
+#include "_cgo_export.h"
+
+/*
+	Go natives.
+
+	RegisterNatives needs a C function pointer per native, so a fixed pool of
+	NATIVE_SLOTS trampolines is generated here;  each one is declared with
+	every integer register, floating point register and stack slot a JNI call
+	may pass arguments in, and spills them all into NativeArgs for goNative
+	(which knows the Java signature registered for the slot) to pick apart.
+
+	Slots come in two flavours, by where the return value goes:  integers and
+	references in the integer return register, float/double in the floating
+	point one (floats are returned in the low half of the double).
+*/
+
+#if NATIVE_INT_REGS == 4
+#define INT_PARAMS	jlong i0, jlong i1, jlong i2, jlong i3
+#define INT_SPILL(a)	(a).ints[0] = i0; (a).ints[1] = i1; (a).ints[2] = i2; (a).ints[3] = i3;
+#elif NATIVE_INT_REGS == 6
+#define INT_PARAMS	jlong i0, jlong i1, jlong i2, jlong i3, jlong i4, jlong i5
+#define INT_SPILL(a)	(a).ints[0] = i0; (a).ints[1] = i1; (a).ints[2] = i2; (a).ints[3] = i3; \
+	(a).ints[4] = i4; (a).ints[5] = i5;
+#endif
+
+#if NATIVE_INT_REGS > 0
+
+#define FP_PARAMS	jdouble f0, jdouble f1, jdouble f2, jdouble f3, jdouble f4, jdouble f5, jdouble f6, jdouble f7
+#define FP_SPILL(a)	(a).fps[0] = f0; (a).fps[1] = f1; (a).fps[2] = f2; (a).fps[3] = f3; \
+	(a).fps[4] = f4; (a).fps[5] = f5; (a).fps[6] = f6; (a).fps[7] = f7;
+
+#define STACK_PARAMS	jlong s0, jlong s1, jlong s2, jlong s3, jlong s4, jlong s5, jlong s6, jlong s7, \
+	jlong s8, jlong s9, jlong s10, jlong s11, jlong s12, jlong s13, jlong s14, jlong s15
+#define STACK_SPILL(a)	(a).stack[0] = s0; (a).stack[1] = s1; (a).stack[2] = s2; (a).stack[3] = s3; \
+	(a).stack[4] = s4; (a).stack[5] = s5; (a).stack[6] = s6; (a).stack[7] = s7; \
+	(a).stack[8] = s8; (a).stack[9] = s9; (a).stack[10] = s10; (a).stack[11] = s11; \
+	(a).stack[12] = s12; (a).stack[13] = s13; (a).stack[14] = s14; (a).stack[15] = s15;
+
+#define TRAMPOLINE(n) \
+static jlong nativeInt##n(JNIEnv *env, jobject obj, INT_PARAMS, FP_PARAMS, STACK_PARAMS) { \
+	NativeArgs a; \
+	memset(&a, 0, sizeof(a)); \
+	INT_SPILL(a) FP_SPILL(a) STACK_SPILL(a) \
+	goNative(n, env, obj, &a); \
+	return a.ret.j; \
+} \
+static jdouble nativeFP##n(JNIEnv *env, jobject obj, INT_PARAMS, FP_PARAMS, STACK_PARAMS) { \
+	NativeArgs a; \
+	memset(&a, 0, sizeof(a)); \
+	INT_SPILL(a) FP_SPILL(a) STACK_SPILL(a) \
+	goNative(n, env, obj, &a); \
+	return a.ret.d; \
+}
+#define INT_SLOT(n)	(void *)nativeInt##n,
+#define FP_SLOT(n)	(void *)nativeFP##n,
+
+// expands M for the slot numbers 0x00 .. 0xff
+#define SLOTS16(M, p)	M(p##0) M(p##1) M(p##2) M(p##3) M(p##4) M(p##5) M(p##6) M(p##7) \
+	M(p##8) M(p##9) M(p##a) M(p##b) M(p##c) M(p##d) M(p##e) M(p##f)
+#define SLOTS(M)	SLOTS16(M, 0x0) SLOTS16(M, 0x1) SLOTS16(M, 0x2) SLOTS16(M, 0x3) \
+	SLOTS16(M, 0x4) SLOTS16(M, 0x5) SLOTS16(M, 0x6) SLOTS16(M, 0x7) \
+	SLOTS16(M, 0x8) SLOTS16(M, 0x9) SLOTS16(M, 0xa) SLOTS16(M, 0xb) \
+	SLOTS16(M, 0xc) SLOTS16(M, 0xd) SLOTS16(M, 0xe) SLOTS16(M, 0xf)
+
+SLOTS(TRAMPOLINE)
+
+static void *intSlots[NATIVE_SLOTS] = { SLOTS(INT_SLOT) };
+static void *fpSlots[NATIVE_SLOTS] = { SLOTS(FP_SLOT) };
+
+/* the trampoline for slot, or NULL if out of range */
+void *nativeTrampoline(int slot, jboolean fp){
+	if (slot < 0 || slot >= NATIVE_SLOTS) {
+		return NULL;
+	}
+	return fp ? fpSlots[slot] : intSlots[slot];
+}
+
+#else
+
+/* unsupported calling convention;  Go natives are unavailable */
+void *nativeTrampoline(int slot, jboolean fp){
+	return NULL;
+}
+
+#endif
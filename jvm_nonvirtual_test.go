@@ -0,0 +1,32 @@
+package gojvm
+
+import (
+	"github.com/timob/gojvm/types"
+	"strings"
+	"testing"
+)
+
+func TestJVMNonvirtualCall(t *testing.T) {
+	env := setupJVM(t)
+	list, err := env.NewInstanceStr("java/util/ArrayList")
+	fatalIf(t, err != nil, "Couldn't instantiate ArrayList: %v", err)
+	defer env.DeleteLocalRef(list)
+	objClass, err := env.GetClass(types.JavaLangObject)
+	fatalIf(t, err != nil, "Couldn't get Object class: %v", err)
+
+	s, _, err := list.CallString(env, false, "toString")
+	fatalIf(t, err != nil, "Couldn't call toString: %v", err)
+	fatalInEq(t, "[]", s, "Wrong (virtual) toString")
+
+	v, err := list.CallNonvirtual(env, objClass, "toString", types.Class{types.JavaLangString})
+	fatalIf(t, err != nil, "Couldn't call Object.toString: %v", err)
+	s, _, err = env.ToString(v.(*Object))
+	fatalIf(t, err != nil, "Couldn't convert string: %v", err)
+	fatalIf(t, !strings.HasPrefix(s, "java.util.ArrayList@"), "Wrong (nonvirtual) toString: %q", s)
+
+	v, err = list.CallNonvirtual(env, objClass, "hashCode", types.Basic(types.IntKind))
+	fatalIf(t, err != nil, "Couldn't call Object.hashCode: %v", err)
+	ident, err := env.CallClassInt(systemClass(env, t), true, "identityHashCode", list)
+	fatalIf(t, err != nil, "Couldn't call identityHashCode: %v", err)
+	fatalInEq(t, ident, v, "Object.hashCode should be the identity hash")
+}
@@ -0,0 +1,50 @@
+package gojvm
+
+import (
+	"testing"
+)
+
+var NullsClass = "org/golang/ext/gojvm/testing/Nulls"
+/* Methods taking (overloaded) nullable parameters;
+
+Verifies:
+	Typed (Null) and untyped (nil) null parameters
+	Neither is passed for a primitive parameter (as 0)
+*/
+
+func TestJVMNullParams(t *testing.T) {
+	env := setupJVM(t)
+	obj, err := env.NewInstanceStr(NullsClass)
+	fatalIf(t, err != nil, "Couldn't instantiate Nulls: %v", err)
+	defer env.DeleteLocalRef(obj)
+
+	for _, k := range []string{"String", "Integer"} {
+		s, _, err := obj.CallString(env, false, "Which", Null("java/lang/"+k))
+		fatalIf(t, err != nil, "Couldn't call Which(%s): %v", k, err)
+		fatalInEq(t, k+":null", s, "Wrong overload for typed null")
+	}
+
+	isNull, err := obj.CallBool(env, false, "IsNull", nil)
+	fatalIf(t, err != nil, "Couldn't call IsNull: %v", err)
+	fatalIf(t, !isNull, "IsNull(nil) was false")
+
+	i, err := obj.CallInt(env, false, "Length", nil)
+	fatalIf(t, err != nil, "Couldn't call Length: %v", err)
+	fatalInEq(t, -1, i, "Wrong Length(nil)")
+
+	i, err = obj.CallInt(env, false, "Count", Null("[I"))
+	fatalIf(t, err != nil, "Couldn't call Count: %v", err)
+	fatalInEq(t, -1, i, "Wrong Count(null)")
+
+	integer, err := env.GetClassStr("java/lang/Integer")
+	fatalIf(t, err != nil, "Couldn't get Integer: %v", err)
+	_, _, err = integer.CallString(env, true, "toHexString", Null("java/lang/Integer"))
+	fatalIf(t, err != ErrNullUnbox, "Passing a typed null for an int should fail (got %v)", err)
+}
+
+func TestJVMNullForm(t *testing.T) {
+	env := setupJVM(t)
+	form, err := FormFor(env, Null("[I").Type, Null("java/lang/String"), nil)
+	fatalIf(t, err != nil, "Couldn't form nulls: %v", err)
+	fatalInEq(t, "(Ljava/lang/String;Ljava/lang/Object;)[I", form, "Wrong form")
+}
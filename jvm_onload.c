@@ -0,0 +1,24 @@
+//go:build gojvm_onload
+
+#include "helpers.h"
+#include "_cgo_export.h"
+
+/*
+	Entry points for a Go c-shared library loaded with System.loadLibrary;
+	the VM is adopted (see OnLoad) and the library asks for the default
+	JNI version.
+
+	Only built with the gojvm_onload tag (or, with the Makefile, ONLOAD=1),
+	so that other binaries linking gojvm don't export JNI_OnLoad.
+*/
+JNIEXPORT jint JNICALL JNI_OnLoad(JavaVM *vm, void *reserved){
+	JNIEnv *env;
+	if ((*vm)->GetEnv(vm, (void **)&env, JNI_VERSION_1_6) != JNI_OK) {
+		return JNI_ERR;
+	}
+	return goJNIOnLoad(vm, env);
+}
+
+JNIEXPORT void JNICALL JNI_OnUnload(JavaVM *vm, void *reserved){
+	goJNIOnUnload(vm);
+}
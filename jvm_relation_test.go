@@ -0,0 +1,61 @@
+package gojvm
+
+import (
+	"github.com/timob/gojvm/types"
+	"testing"
+)
+
+/* Relationships between java.util (and java.lang) classes and instances;
+
+Verifies:
+	IsInstanceOf, IsAssignableFrom, Superclass & IsSameObject
+	Cast checks the instance before casting
+*/
+
+func TestJVMTypeRelations(t *testing.T) {
+	env := setupJVM(t)
+	list, err := env.GetClassStr("java/util/List")
+	fatalIf(t, err != nil, "Couldn't get List: %v", err)
+	arrayList, err := env.GetClassStr("java/util/ArrayList")
+	fatalIf(t, err != nil, "Couldn't get ArrayList: %v", err)
+	str, err := env.GetClassStr("java/lang/String")
+	fatalIf(t, err != nil, "Couldn't get String: %v", err)
+
+	fatalIf(t, !env.IsAssignableFrom(list, arrayList), "ArrayList should be assignable to List")
+	fatalIf(t, env.IsAssignableFrom(arrayList, list), "List shouldn't be assignable to ArrayList")
+
+	obj, err := env.NewInstance(arrayList)
+	fatalIf(t, err != nil, "Couldn't construct ArrayList: %v", err)
+	fatalIf(t, !env.IsInstanceOf(obj, list), "ArrayList instance should be a List")
+	fatalIf(t, env.IsInstanceOf(obj, str), "ArrayList instance isn't a String")
+	fatalIf(t, env.IsInstanceOf(nil, list), "null is an instance of nothing")
+
+	super := arrayList.Superclass(env)
+	fatalIf(t, super == nil, "ArrayList has a superclass")
+	name, err := super.GetName(env)
+	fatalIf(t, err != nil, "Couldn't name superclass: %v", err)
+	fatalInEq(t, "java/util/AbstractList", name.AsPath(), "Wrong superclass")
+	env.DeleteLocalClassRef(super)
+	fatalIf(t, list.Superclass(env) != nil, "Interfaces have no superclass")
+
+	ref := env.NewLocalRef(obj)
+	fatalIf(t, !env.IsSameObject(obj, ref), "A new ref is the same object")
+	env.DeleteLocalRef(ref)
+	other, err := env.NewInstance(arrayList)
+	fatalIf(t, err != nil, "Couldn't construct ArrayList: %v", err)
+	fatalIf(t, env.IsSameObject(obj, other), "Distinct instances aren't the same object")
+	fatalIf(t, !env.IsSameObject(nil, nil), "null is null")
+}
+
+func TestJVMCheckedCast(t *testing.T) {
+	env := setupJVM(t)
+	obj, err := env.NewInstanceStr("java/util/ArrayList")
+	fatalIf(t, err != nil, "Couldn't construct ArrayList: %v", err)
+
+	cast, err := env.Cast(obj, types.NewName("java/util/Collection"))
+	fatalIf(t, err != nil, "Couldn't cast to Collection: %v", err)
+	fatalInEq(t, "java/util/Collection", cast.Name.AsPath(), "Wrong cast name")
+
+	_, err = env.Cast(obj, types.NewName("java/lang/String"))
+	fatalIf(t, err != ErrBadCast, "Casting an ArrayList to String should fail (got %v)", err)
+}
@@ -0,0 +1,47 @@
+package gojvm
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+/* Go shutdown hooks;  as a JVM can't be recreated in a process, each shutdown
+runs in a child test process (GOJVM_HOOK set), leaving the shared _jvm alone;
+
+Verifies:
+	Go shutdown hooks run on Destroy, and on System.exit
+*/
+
+// a child JVM (GOJVM_HOOK=destroy|exit) with a Go shutdown hook, shut down from either side
+func TestJVMShutdownHook(t *testing.T) {
+	if os.Getenv("GOJVM_HOOK") == "" {
+		for _, how := range []string{"destroy", "exit"} {
+			cmd := exec.Command(os.Args[0], "-test.run=TestJVMShutdownHook")
+			cmd.Env = append(os.Environ(), "GOJVM_HOOK="+how)
+			out, err := cmd.CombinedOutput()
+			fatalIf(t, err != nil, "[%s] Child failed: %v\n%s", how, err, out)
+			fatalIf(t, !strings.Contains(string(out), "hook ran"), "[%s] Shutdown hook didn't run:\n%s", how, out)
+		}
+		return
+	}
+	env := setupJVM(t)
+	err := _jvm.AddShutdownHook(func(env *Environment) {
+		_, err := env.GetClassStr(SystemClass)
+		if err == nil {
+			fmt.Println("hook ran")
+		}
+	})
+	fatalIf(t, err != nil, "Couldn't add shutdown hook: %v", err)
+	if os.Getenv("GOJVM_HOOK") == "destroy" {
+		err = _jvm.Destroy()
+		fatalIf(t, err != nil, "Couldn't destroy the JVM: %v", err)
+		return
+	}
+	system, err := env.GetClassStr(SystemClass)
+	fatalIf(t, err != nil, "Couldn't load System: %v", err)
+	system.CallVoid(env, true, "exit", 0)
+	t.Fatalf("System.exit returned")
+}
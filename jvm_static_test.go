@@ -0,0 +1,82 @@
+package gojvm
+
+import (
+	"github.com/timob/gojvm/types"
+	"testing"
+)
+
+var StaticsClass = "org/golang/ext/gojvm/testing/Statics"
+/* A static field of each type;
+
+Verifies:
+	Class.Set*Field / Class.Get*Field round trips
+*/
+
+func TestJVMStaticFieldSetters(t *testing.T) {
+	env := setupJVM(t)
+	klass, err := env.GetClassStr(StaticsClass)
+	fatalIf(t, err != nil, "Couldn't get Statics: %v", err)
+
+	fatalIf(t, klass.SetBooleanField(env, true, "Z", true) != nil, "Couldn't set Z")
+	z, err := klass.GetBooleanField(env, true, "Z")
+	fatalIf(t, err != nil || !z, "Wrong Z (%v, %v)", z, err)
+
+	fatalIf(t, klass.SetByteField(env, true, "B", -8) != nil, "Couldn't set B")
+	b, err := klass.GetByteField(env, true, "B")
+	fatalIf(t, err != nil || b != -8, "Wrong B (%v, %v)", b, err)
+
+	fatalIf(t, klass.SetCharField(env, true, "C", 'q') != nil, "Couldn't set C")
+	c, err := klass.GetCharField(env, true, "C")
+	fatalIf(t, err != nil || c != 'q', "Wrong C (%v, %v)", c, err)
+
+	fatalIf(t, klass.SetShortField(env, true, "S", -5128) != nil, "Couldn't set S")
+	s, err := klass.GetShortField(env, true, "S")
+	fatalIf(t, err != nil || s != -5128, "Wrong S (%v, %v)", s, err)
+
+	fatalIf(t, klass.SetIntField(env, true, "I", 1<<20) != nil, "Couldn't set I")
+	i, err := klass.GetIntField(env, true, "I")
+	fatalIf(t, err != nil || i != 1<<20, "Wrong I (%v, %v)", i, err)
+
+	fatalIf(t, klass.SetLongField(env, true, "J", 1<<40) != nil, "Couldn't set J")
+	j, err := klass.GetLongField(env, true, "J")
+	fatalIf(t, err != nil || j != 1<<40, "Wrong J (%v, %v)", j, err)
+
+	fatalIf(t, klass.SetFloatField(env, true, "F", .5) != nil, "Couldn't set F")
+	f, err := klass.GetFloatField(env, true, "F")
+	fatalIf(t, err != nil || f != .5, "Wrong F (%v, %v)", f, err)
+
+	fatalIf(t, klass.SetDoubleField(env, true, "D", -.25) != nil, "Couldn't set D")
+	d, err := klass.GetDoubleField(env, true, "D")
+	fatalIf(t, err != nil || d != -.25, "Wrong D (%v, %v)", d, err)
+}
+
+func TestJVMStaticObjectSetters(t *testing.T) {
+	env := setupJVM(t)
+	klass, err := env.GetClassStr(StaticsClass)
+	fatalIf(t, err != nil, "Couldn't get Statics: %v", err)
+
+	str, err := env.NewStringObject("static")
+	fatalIf(t, err != nil, "Couldn't make string: %v", err)
+	defer env.DeleteLocalRef(str)
+	err = klass.SetObjField(env, true, "Str", types.Class{types.JavaLangString}, str)
+	fatalIf(t, err != nil, "Couldn't set Str: %v", err)
+	obj, err := klass.GetObjField(env, true, "Str", types.Class{types.JavaLangString})
+	fatalIf(t, err != nil, "Couldn't get Str: %v", err)
+	gs, _, err := env.ToString(obj)
+	fatalIf(t, err != nil || gs != "static", "Wrong Str (%q, %v)", gs, err)
+
+	err = klass.SetArrayField(env, true, "Ints", []int{1, 2, 3})
+	fatalIf(t, err != nil, "Couldn't set Ints: %v", err)
+	n, err := klass.CallInt(env, true, "IntsLength")
+	fatalIf(t, err != nil || n != 3, "Wrong Ints length (%v, %v)", n, err)
+
+	err = klass.SetArrayField(env, true, "Strs", []string{"a", "b"})
+	fatalIf(t, err != nil, "Couldn't set Strs: %v", err)
+	gs, _, err = klass.CallString(env, true, "LastStr")
+	fatalIf(t, err != nil || gs != "b", "Wrong Strs (%q, %v)", gs, err)
+
+	err = klass.SetObjField(env, true, "Ints", types.Array{types.Basic(types.IntKind)}, nil)
+	fatalIf(t, err != nil, "Couldn't null Ints: %v", err)
+	n, err = klass.CallInt(env, true, "IntsLength")
+	fatalIf(t, err != nil || n != -1, "Ints wasn't nulled (%v, %v)", n, err)
+}
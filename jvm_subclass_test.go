@@ -0,0 +1,123 @@
+package gojvm
+
+import (
+	"github.com/timob/gojvm/types"
+	"testing"
+)
+
+/* Java subclasses with Go overrides, of java/util/TimerTask and java/io/FilterInputStream;
+
+Verifies:
+	Subclass overrides abstract and concrete methods with Go funcs
+	Constructors pass their arguments on to the superclass
+	Inherited methods still call the superclass's
+	Overrides are bound natives, and Unsubclass gives their slots back
+	Without free slots, overrides dispatch through GoOverride.invoke
+	Final classes, and names without a fitting method, are refused
+*/
+
+func TestJVMSubclassTimerTask(t *testing.T) {
+	env := setupJVM(t)
+	runs := 0
+	class, err := env.Subclass("java/util/TimerTask", map[string]interface{}{
+		"run": func(env *Environment, this *Object) { runs++ },
+	})
+	fatalIf(t, err != nil, "Couldn't subclass TimerTask: %v", err)
+	defer env.DeleteGlobalClassRef(class)
+	defer env.Unsubclass(class)
+	task, err := env.NewInstance(class)
+	fatalIf(t, err != nil, "Couldn't make a task: %v", err)
+	defer env.DeleteGlobalRef(task)
+	for i := 0; i < 2; i++ {
+		err = task.CallVoid(env, false, "run")
+		fatalIf(t, err != nil, "[%d] Couldn't run: %v", i, err)
+	}
+	fatalInEq(t, 2, runs, "Wrong run count")
+
+	cancelled, err := task.CallBool(env, false, "cancel")
+	fatalIf(t, err != nil, "Couldn't call the inherited cancel: %v", err)
+	fatalInEq(t, true, cancelled, "Wrong cancel result")
+}
+
+func TestJVMSubclassFilterInputStream(t *testing.T) {
+	env := setupJVM(t)
+	testFilterSubclass(t, env)
+
+	_, err := env.Subclass("java/lang/String", map[string]interface{}{})
+	fatalIf(t, err != ErrNotSubclassable, "Subclassing String should fail (got %v)", err)
+	_, err = env.Subclass("java/io/FilterInputStream", map[string]interface{}{
+		"available": func(env *Environment, this *Object) string { return "" },
+	})
+	fatalIf(t, err != ErrNoOverride, "A String available shouldn't override (got %v)", err)
+}
+
+func TestJVMSubclassSlots(t *testing.T) {
+	env := setupJVM(t)
+	overrides := map[string]interface{}{
+		"run": func(env *Environment, this *Object) {},
+	}
+	inUse := func() int {
+		env.jvm.nativeLock.Lock()
+		defer env.jvm.nativeLock.Unlock()
+		return env.jvm.regId - len(env.jvm.freeSlots)
+	}
+	slots := inUse()
+	for i := 0; i < 3; i++ {
+		class, err := env.Subclass("java/util/TimerTask", overrides)
+		fatalIf(t, err != nil, "[%d] Couldn't subclass TimerTask: %v", i, err)
+		fatalInEq(t, slots+1, inUse(), "Wrong slots in use by a subclass")
+		err = env.Unsubclass(class)
+		fatalIf(t, err != nil, "[%d] Couldn't unsubclass: %v", i, err)
+		env.DeleteGlobalClassRef(class)
+		fatalInEq(t, slots, inUse(), "Unsubclass should give the slot back")
+	}
+}
+
+func TestJVMSubclassWithoutSlots(t *testing.T) {
+	env := setupJVM(t)
+	jvm := env.jvm
+	jvm.nativeLock.Lock()
+	regId, free := jvm.regId, jvm.freeSlots
+	jvm.freeSlots = nil
+	for err := error(nil); err == nil; _, err = jvm.freeSlot() {
+	}
+	jvm.nativeLock.Unlock()
+	defer func() {
+		jvm.nativeLock.Lock()
+		jvm.regId, jvm.freeSlots = regId, free
+		jvm.nativeLock.Unlock()
+	}()
+	testFilterSubclass(t, env)
+}
+
+// subclasses FilterInputStream, and checks its overrides and inherited read
+func testFilterSubclass(t *testing.T, env *Environment) {
+	class, err := env.Subclass("java/io/FilterInputStream", map[string]interface{}{
+		"available":        func(env *Environment, this *Object) int { return 42 },
+		"markSupported()Z": func(env *Environment, this *Object) bool { return true },
+		"skip":             func(env *Environment, this *Object, n int64) int64 { return n * 2 },
+	})
+	fatalIf(t, err != nil, "Couldn't subclass FilterInputStream: %v", err)
+	defer env.DeleteGlobalClassRef(class)
+	defer env.Unsubclass(class)
+
+	in, err := env.NewInstanceStr("java/io/ByteArrayInputStream", []byte("go"))
+	fatalIf(t, err != nil, "Couldn't make a ByteArrayInputStream: %v", err)
+	defer env.DeleteGlobalRef(in)
+	filter, err := env.NewInstance(class, &CastObject{in, types.Name{"java", "io", "InputStream"}})
+	fatalIf(t, err != nil, "Couldn't make the filter: %v", err)
+	defer env.DeleteGlobalRef(filter)
+
+	n, err := filter.CallInt(env, false, "available")
+	fatalIf(t, err != nil, "Couldn't call available: %v", err)
+	fatalInEq(t, 42, n, "Wrong available")
+	b, err := filter.CallBool(env, false, "markSupported")
+	fatalIf(t, err != nil, "Couldn't call markSupported: %v", err)
+	fatalInEq(t, true, b, "Wrong markSupported")
+	skipped, err := filter.CallLong(env, false, "skip", int64(1)<<40)
+	fatalIf(t, err != nil, "Couldn't call skip: %v", err)
+	fatalInEq(t, int64(1)<<41, skipped, "Wrong skip")
+	c, err := filter.CallInt(env, false, "read")
+	fatalIf(t, err != nil, "Couldn't call the inherited read: %v", err)
+	fatalInEq(t, int('g'), c, "Wrong read")
+}
@@ -54,7 +54,7 @@ func TestJVMTrivialClass(t *testing.T) {
 		fatalIf(t, err != nil, "[%d] Error generating formFor: %v", i, err)
 		kused, _, err := klass.CallString(env, false, "getConstructorUsed")
 		fatalIf(t, err != nil, "[%d] Error getting constructor used: %v", i, err)
-		fatalIf(t, kused != form, "[%d] Constructor called was wrong (Exp: %s, got: %s)", form, kused)
+		fatalIf(t, kused != form, "[%d] Constructor called was wrong (Exp: %s, got: %s)", i, form, kused)
 
 	}
 }
@@ -82,12 +82,12 @@ func TestJVMNativeVoidClass(t *testing.T) {
 	//defer defMute(env)()
 	nativePings := 0
 	klass, err := env.GetClassStr(NativeClass)
-	fatalIf(t, err != nil, "Native threw an exception", err)
+	fatalIf(t, err != nil, "Native threw an exception: %v", err)
 	fatalIf(t, klass == nil, "Native klass is nil!")
-	err = env.RegisterNative(klass, "NativePing", func(E *Environment, O *Object) {
+	err = env.RegisterNative(NativeClass, "NativePing", types.MethodSignature{}, func(E *Environment, O *Object) {
 		nativePings += 1
 	})
-	fatalIf(t, err != nil, "RegisterNative threw an exception", err)
+	fatalIf(t, err != nil, "RegisterNative threw an exception: %v", err)
 	obj, err := env.NewInstanceStr(NativeClass)
 	fatalIf(t, err != nil, "Couldn't instantiate NativeClass: %v", err)
 	fatalIf(t, obj == nil, "Instantiated NativeClass is nil")
@@ -100,12 +100,12 @@ func TestJVMNativeIntClass(t *testing.T) {
 	env := setupJVM(t)
 	//defer defMute(env)()
 	klass, err := env.GetClassStr(NativeClass)
-	fatalIf(t, err != nil, "Native threw an exception", err)
+	fatalIf(t, err != nil, "Native threw an exception: %v", err)
 	fatalIf(t, klass == nil, "Native klass is nil!")
-	err = env.RegisterNative(klass, "NativeInt", func(E *Environment, O *Object) (i int) {
+	err = env.RegisterNative(NativeClass, "NativeInt", types.MethodSignature{}, func(E *Environment, O *Object) (i int) {
 		return 15
 	})
-	fatalIf(t, err != nil, "RegisterNative threw an exception", err)
+	fatalIf(t, err != nil, "RegisterNative threw an exception: %v", err)
 	obj, err := env.NewInstanceStr(NativeClass)
 	fatalIf(t, err != nil, "Couldn't instantiate NativeClass: %v", err)
 	fatalIf(t, obj == nil, "Instantiated NativeClass is nil")
@@ -118,59 +118,59 @@ func TestJVMNativeComplexClass(t *testing.T) {
 	env := setupJVM(t)
 	//defer defMute(env)()
 	klass, err := env.GetClassStr(NativeClass)
-	fatalIf(t, err != nil, "Native threw an exception", err)
+	fatalIf(t, err != nil, "Native threw an exception: %v", err)
 	fatalIf(t, klass == nil, "Native klass is nil!")
 	obj1, err := env.NewInstanceStr("java/lang/Object")
-	fatalIf(t, err != nil, "new(Object) threw an exception", err)
+	fatalIf(t, err != nil, "new(Object) threw an exception: %v", err)
 	obj2, err := env.NewInstanceStr("java/lang/Object")
-	fatalIf(t, err != nil, "new(Object2) threw an exception", err)
+	fatalIf(t, err != nil, "new(Object2) threw an exception: %v", err)
 	hit := false
-	err = env.RegisterNative(klass, "NativeComplex", func(E *Environment, O *Object, o1 *Object, o2 *Object, i1 int) {
+	err = env.RegisterNative(NativeClass, "NativeComplex", types.MethodSignature{}, func(E *Environment, O *Object, o1 *Object, o2 *Object, i1 int) {
 		hit = true
 	})
-	fatalIf(t, err != nil, "RegisterNative threw an exception", err)
+	fatalIf(t, err != nil, "RegisterNative threw an exception: %v", err)
 	obj, err := env.NewInstanceStr(NativeClass)
 	fatalIf(t, err != nil, "Couldn't instantiate NativeClass: %v", err)
 	fatalIf(t, obj == nil, "Instantiated NativeClass is nil")
 	err = env.CallObjectVoid(obj, false, "NativeComplex", obj1, obj2, 13)
 	fatalIf(t, err != nil, "Couldn't call NativeClass.NativeComplex(): %v", err)
-	fatalIf(t, !hit, "Native complex never got called", err)
+	fatalIf(t, !hit, "Native complex never got called: %v", err)
 }
 
 func TestJVMNativeBoolClass(t *testing.T) {
 	env := setupJVM(t)
 	//defer defMute(env)()
 	klass, err := env.GetClassStr(NativeClass)
-	fatalIf(t, err != nil, "Native threw an exception", err)
+	fatalIf(t, err != nil, "Native threw an exception: %v", err)
 	fatalIf(t, klass == nil, "Native klass is nil!")
 	hit := false
-	err = env.RegisterNative(klass, "NativeBool", func(E *Environment, O *Object) bool {
+	err = env.RegisterNative(NativeClass, "NativeBool", types.MethodSignature{}, func(E *Environment, O *Object) bool {
 		return !hit
 	})
-	fatalIf(t, err != nil, "RegisterNative threw an exception", err)
+	fatalIf(t, err != nil, "RegisterNative threw an exception: %v", err)
 	obj, err := env.NewInstanceStr(NativeClass)
 	fatalIf(t, err != nil, "Couldn't instantiate NativeClass: %v", err)
 	fatalIf(t, obj == nil, "Instantiated NativeClass is nil")
 	ok, err := obj.CallBool(env, false, "NativeBool")
 	fatalIf(t, err != nil, "Couldn't call NativeClass.NativeBool(): %v", err)
-	fatalIf(t, ok == hit, "Native complex never got called", err)
+	fatalIf(t, ok == hit, "Native complex never got called: %v", err)
 	hit = !hit
 	ok, err = obj.CallBool(env, false, "NativeBool")
 	fatalIf(t, err != nil, "Couldn't call NativeClass.NativeBool(): %v", err)
-	fatalIf(t, ok == hit, "Native complex never got called", err)
+	fatalIf(t, ok == hit, "Native complex never got called: %v", err)
 }
 
 func TestJVMNativeLongClass(t *testing.T) {
 	env := setupJVM(t)
 	//defer defMute(env)()
 	klass, err := env.GetClassStr(NativeClass)
-	fatalIf(t, err != nil, "Native threw an exception", err)
+	fatalIf(t, err != nil, "Native threw an exception: %v", err)
 	fatalIf(t, klass == nil, "Native klass is nil!")
 	hit := int64(0)
-	err = env.RegisterNative(klass, "NativeLong", func(E *Environment, O *Object) int64 {
+	err = env.RegisterNative(NativeClass, "NativeLong", types.MethodSignature{}, func(E *Environment, O *Object) int64 {
 		return hit
 	})
-	fatalIf(t, err != nil, "RegisterNative threw an exception", err)
+	fatalIf(t, err != nil, "RegisterNative threw an exception: %v", err)
 	obj, err := env.NewInstanceStr(NativeClass)
 	fatalIf(t, err != nil, "Couldn't instantiate NativeClass: %v", err)
 	fatalIf(t, obj == nil, "Instantiated NativeClass is nil")
@@ -187,36 +187,36 @@ func TestJVMNativeFloatClass(t *testing.T) {
 	env := setupJVM(t)
 	//defer defMute(env)()
 	klass, err := env.GetClassStr(NativeClass)
-	fatalIf(t, err != nil, "Native threw an exception", err)
+	fatalIf(t, err != nil, "Native threw an exception: %v", err)
 	fatalIf(t, klass == nil, "Native klass is nil!")
 	hit := float32(.1234)
-	err = env.RegisterNative(klass, "NativeFloat", func(E *Environment, O *Object) float32 {
+	err = env.RegisterNative(NativeClass, "NativeFloat", types.MethodSignature{}, func(E *Environment, O *Object) float32 {
 		return hit
 	})
-	fatalIf(t, err != nil, "RegisterNative threw an exception", err)
+	fatalIf(t, err != nil, "RegisterNative threw an exception: %v", err)
 	obj, err := env.NewInstanceStr(NativeClass)
 	fatalIf(t, err != nil, "Couldn't instantiate NativeClass: %v", err)
 	fatalIf(t, obj == nil, "Instantiated NativeClass is nil")
 	ok, err := obj.CallFloat(env, false, "NativeFloat")
 	fatalIf(t, err != nil, "Couldn't call NativeClass.NativeFloat(): %v", err)
-	fatalIf(t, ok != hit, "NativeLong got wrong value: %d", ok)
+	fatalIf(t, ok != hit, "NativeLong got wrong value: %v", ok)
 	hit = 1 / 20
 	ok, err = obj.CallFloat(env, false, "NativeFloat")
 	fatalIf(t, err != nil, "Couldn't call NativeClass.NativeFloat(): %v", err)
-	fatalIf(t, ok != hit, "NativeFloat got wrong value: %d", ok)
+	fatalIf(t, ok != hit, "NativeFloat got wrong value: %v", ok)
 }
 
 func TestJVMNativeShortClass(t *testing.T) {
 	env := setupJVM(t)
 	//defer defMute(env)()
 	klass, err := env.GetClassStr(NativeClass)
-	fatalIf(t, err != nil, "Native threw an exception", err)
+	fatalIf(t, err != nil, "Native threw an exception: %v", err)
 	fatalIf(t, klass == nil, "Native klass is nil!")
 	hit := int16(0)
-	err = env.RegisterNative(klass, "NativeShort", func(E *Environment, O *Object) int16 {
+	err = env.RegisterNative(NativeClass, "NativeShort", types.MethodSignature{}, func(E *Environment, O *Object) int16 {
 		return hit
 	})
-	fatalIf(t, err != nil, "RegisterNative threw an exception", err)
+	fatalIf(t, err != nil, "RegisterNative threw an exception: %v", err)
 	obj, err := env.NewInstanceStr(NativeClass)
 	fatalIf(t, err != nil, "Couldn't instantiate NativeClass: %v", err)
 	fatalIf(t, obj == nil, "Instantiated NativeClass is nil")
@@ -233,47 +233,47 @@ func TestJVMNativeDoubleClass(t *testing.T) {
 	env := setupJVM(t)
 	//defer defMute(env)()
 	klass, err := env.GetClassStr(NativeClass)
-	fatalIf(t, err != nil, "Native threw an exception", err)
+	fatalIf(t, err != nil, "Native threw an exception: %v", err)
 	fatalIf(t, klass == nil, "Native klass is nil!")
 	hit := float64(1234)
-	err = env.RegisterNative(klass, "NativeDouble", func(E *Environment, O *Object) float64 {
+	err = env.RegisterNative(NativeClass, "NativeDouble", types.MethodSignature{}, func(E *Environment, O *Object) float64 {
 		return hit
 	})
-	fatalIf(t, err != nil, "RegisterNative threw an exception", err)
+	fatalIf(t, err != nil, "RegisterNative threw an exception: %v", err)
 	obj, err := env.NewInstanceStr(NativeClass)
 	fatalIf(t, err != nil, "Couldn't instantiate NativeClass: %v", err)
 	defer env.DeleteLocalRef(obj)
 	fatalIf(t, obj == nil, "Instantiated NativeClass is nil")
 	ok, err := obj.CallDouble(env, false, "NativeDouble")
 	fatalIf(t, err != nil, "Couldn't call NativeClass.NativeDouble(): %v", err)
-	fatalIf(t, ok != hit, "NativeDouble got wrong value: %d", ok)
+	fatalIf(t, ok != hit, "NativeDouble got wrong value: %v", ok)
 	hit = float64(-125 / 7)
 	ok, err = obj.CallDouble(env, false, "NativeDouble")
 	fatalIf(t, err != nil, "Couldn't call NativeClass.NativeDouble(): %v", err)
-	fatalIf(t, ok != hit, "NativeDouble got wrong value: %d", ok)
+	fatalIf(t, ok != hit, "NativeDouble got wrong value: %v", ok)
 }
 
 func TestJVMNativeStringClass(t *testing.T) {
 	env := setupJVM(t)
 	//defer defMute(env)()
 	klass, err := env.GetClassStr(NativeClass)
-	fatalIf(t, err != nil, "Native threw an exception", err)
+	fatalIf(t, err != nil, "Native threw an exception: %v", err)
 	fatalIf(t, klass == nil, "Native klass is nil!")
 	s := "test-string"
-	err = env.RegisterNative(klass, "NativeString", func(E *Environment, O *Object) string {
+	err = env.RegisterNative(NativeClass, "NativeString", types.MethodSignature{}, func(E *Environment, O *Object) string {
 		return s
 	})
-	fatalIf(t, err != nil, "RegisterNative threw an exception", err)
+	fatalIf(t, err != nil, "RegisterNative threw an exception: %v", err)
 	obj, err := env.NewInstanceStr(NativeClass)
 	fatalIf(t, err != nil, "Couldn't instantiate NativeClass: %v", err)
 	fatalIf(t, obj == nil, "Instantiated NativeClass is nil")
 	ok, _, err := obj.CallString(env, false, "NativeString")
 	fatalIf(t, err != nil, "Couldn't call NativeClass.NativeString(): %v", err)
-	fatalIf(t, ok != s, "NativeString got wrong value: %d", ok)
+	fatalIf(t, ok != s, "NativeString got wrong value: %v", ok)
 	s = "testStr2"
 	ok, _, err = obj.CallString(env, false, "NativeString")
 	fatalIf(t, err != nil, "Couldn't call NativeClass.NativeString(): %v", err)
-	fatalIf(t, ok != s, "NativeString got wrong value: %d", ok)
+	fatalIf(t, ok != s, "NativeString got wrong value: %v", ok)
 }
 
 func BenchmarkJVMNativePing(b *testing.B) {
@@ -291,7 +291,7 @@ func BenchmarkJVMNativePing(b *testing.B) {
 		print("benchmark failed: ", err.Error(), "\n")
 		return
 	}
-	err = env.RegisterNative(klass, "NativePing", func(E *Environment, O *Object) {
+	err = env.RegisterNative(NativeClass, "NativePing", types.MethodSignature{}, func(E *Environment, O *Object) {
 		nativePings += 1
 	})
 	if err != nil {
@@ -0,0 +1,222 @@
+package gojvm
+
+import (
+	"github.com/timob/gojvm/types"
+	"reflect"
+)
+
+var objectType = reflect.TypeOf(&Object{})
+var environmentType = reflect.TypeOf(&Environment{})
+
+/*
+	A Go func standing in for a Java functional interface;  fn may take the
+	calling *Environment first, then the interface's parameters.
+*/
+type lambda struct {
+	fn      reflect.Value
+	withEnv bool
+}
+
+/*
+	Checks that fn is a func of nin parameters (after an optional leading
+	*Environment) and nout results;  if out is given, the result must be of
+	that type, otherwise of one callObj can return (*Object, string or a Go
+	primitive).
+*/
+func newLambda(fn interface{}, nin, nout int, out reflect.Type) (l lambda, err error) {
+	l.fn = reflect.ValueOf(fn)
+	if !l.fn.IsValid() || l.fn.Kind() != reflect.Func {
+		return l, ErrBadLambda
+	}
+	ft := l.fn.Type()
+	l.withEnv = ft.NumIn() > 0 && ft.In(0) == environmentType
+	if l.withEnv {
+		nin++
+	}
+	if ft.NumIn() != nin || ft.NumOut() != nout {
+		return l, ErrBadLambda
+	}
+	if nout == 1 {
+		rt := ft.Out(0)
+		if out != nil && rt != out {
+			return l, ErrBadLambda
+		}
+		if rt != objectType && rt != reflect.TypeOf("") && !isPrimitive(reflect.Zero(rt).Interface()) {
+			return l, ErrBadLambda
+		}
+	}
+	return
+}
+
+// calls fn with args converted to its parameter types, and returns its result (if any)
+func (self lambda) call(env *Environment, args ...*Object) (out reflect.Value) {
+	ft := self.fn.Type()
+	in := []reflect.Value{}
+	if self.withEnv {
+		in = append(in, reflect.ValueOf(env))
+	}
+	for _, arg := range args {
+		v, err := env.lambdaArg(arg, ft.In(len(in)))
+		if err != nil {
+			panic(err)
+		}
+		in = append(in, v)
+	}
+	if outs := self.fn.Call(in); len(outs) > 0 {
+		out = outs[0]
+	}
+	return
+}
+
+// calls fn, returning its result as an Object (boxed, if primitive)
+func (self lambda) callObj(env *Environment, args ...*Object) *Object {
+	out := self.call(env, args...)
+	if out.Kind() == reflect.Ptr && out.IsNil() {
+		return nil
+	}
+	result, err := env.boxedResult(out)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+/*
+	converts an Object argument of a functional interface to the Go type pt:
+	*Object as is, string via ToString, primitives by unboxing;  an
+	interface{} gets the unboxed value, a string, or the *Object.
+*/
+func (self *Environment) lambdaArg(arg *Object, pt reflect.Type) (v reflect.Value, err error) {
+	if pt == objectType {
+		return reflect.ValueOf(arg), nil
+	}
+	if pt.Kind() == reflect.String {
+		var s string
+		s, _, err = self.ToString(arg)
+		return reflect.ValueOf(s).Convert(pt), err
+	}
+	if pt.Kind() == reflect.Interface {
+		if arg == nil || arg.object == nil {
+			return reflect.Zero(pt), nil
+		}
+		var name types.Name
+		if name, err = arg.Name(self); err != nil {
+			return
+		}
+		var u interface{}
+		if name.Cmp(types.JavaLangString) == 0 {
+			u, _, err = self.ToString(arg)
+		} else if u, err = self.Unbox(arg); err == ErrNotBoxed {
+			u, err = arg, nil
+		}
+		if err != nil {
+			return
+		}
+		return reflect.ValueOf(u), nil
+	}
+	u, err := self.Unbox(arg)
+	if err != nil {
+		return
+	}
+	v = reflect.ValueOf(u)
+	if !v.Type().ConvertibleTo(pt) {
+		return v, ErrWrongKind
+	}
+	return v.Convert(pt), nil
+}
+
+// the Go methods Implement binds for each interface
+type lambdaFunction struct{ lambda }
+type lambdaSupplier struct{ lambda }
+type lambdaConsumer struct{ lambda }
+type lambdaPredicate struct{ lambda }
+type lambdaBiFunction struct{ lambda }
+type lambdaRunnable struct{ lambda }
+
+func (self lambdaFunction) Apply(env *Environment, proxy *Object, a *Object) *Object {
+	return self.callObj(env, a)
+}
+
+func (self lambdaSupplier) Get(env *Environment, proxy *Object) *Object {
+	return self.callObj(env)
+}
+
+func (self lambdaConsumer) Accept(env *Environment, proxy *Object, a *Object) {
+	self.call(env, a)
+}
+
+func (self lambdaPredicate) Test(env *Environment, proxy *Object, a *Object) bool {
+	return self.call(env, a).Bool()
+}
+
+func (self lambdaBiFunction) Apply(env *Environment, proxy *Object, a, b *Object) *Object {
+	return self.callObj(env, a, b)
+}
+
+func (self lambdaRunnable) Run(env *Environment, proxy *Object) {
+	self.call(env)
+}
+
+/*
+	Returns a java/util/function/Function calling fn, a func(T) R (or
+	func(*Environment, T) R, to be handed the environment of the calling
+	thread).
+
+	As for all the functional wrappers, arguments are converted to T (an
+	*Object, string, Go primitive (unboxed), or interface{}), and results
+	(R is an *Object, string or Go primitive) returned as objects (strings
+	and Go primitives boxed).  The result is a
+	global ref, backed by fn until Unimplement (see Implement).
+*/
+func (self *Environment) Func(fn interface{}) (*Object, error) {
+	l, err := newLambda(fn, 1, 1, nil)
+	if err != nil {
+		return nil, err
+	}
+	return self.Implement("java/util/function/Function", lambdaFunction{l})
+}
+
+// Returns a java/util/function/Supplier calling fn, a func() R (see Func)
+func (self *Environment) Supplier(fn interface{}) (*Object, error) {
+	l, err := newLambda(fn, 0, 1, nil)
+	if err != nil {
+		return nil, err
+	}
+	return self.Implement("java/util/function/Supplier", lambdaSupplier{l})
+}
+
+// Returns a java/util/function/Consumer calling fn, a func(T) (see Func)
+func (self *Environment) Consumer(fn interface{}) (*Object, error) {
+	l, err := newLambda(fn, 1, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	return self.Implement("java/util/function/Consumer", lambdaConsumer{l})
+}
+
+// Returns a java/util/function/Predicate calling fn, a func(T) bool (see Func)
+func (self *Environment) Predicate(fn interface{}) (*Object, error) {
+	l, err := newLambda(fn, 1, 1, reflect.TypeOf(true))
+	if err != nil {
+		return nil, err
+	}
+	return self.Implement("java/util/function/Predicate", lambdaPredicate{l})
+}
+
+// Returns a java/util/function/BiFunction calling fn, a func(T, U) R (see Func)
+func (self *Environment) BiFunction(fn interface{}) (*Object, error) {
+	l, err := newLambda(fn, 2, 1, nil)
+	if err != nil {
+		return nil, err
+	}
+	return self.Implement("java/util/function/BiFunction", lambdaBiFunction{l})
+}
+
+// Returns a java/lang/Runnable calling fn, a func() (see Func)
+func (self *Environment) Runnable(fn interface{}) (*Object, error) {
+	l, err := newLambda(fn, 0, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	return self.Implement("java/lang/Runnable", lambdaRunnable{l})
+}
@@ -1,7 +1,6 @@
 package gojvm
 
 //#cgo CFLAGS:-I../include/
-//#cgo LDFLAGS:-ljvm	-L/usr/lib/jvm/java-6-sun/jre/lib/amd64/server/
 //#include "helpers.h"
 import "C"
 import (
@@ -11,16 +10,45 @@ import (
 	"reflect"
 )
 
+/*
+	A placeholder type for an untyped nil (or null *Object) parameter;  it
+	reflects as java/lang/Object, but method matching will accept it for any
+	declared object or array parameter (see conversionCost).
+*/
+type nullType struct{}
+
+func (self nullType) TypeString() string { return types.Class{types.JavaLangObject}.TypeString() }
+func (self nullType) Kind() types.Kind   { return types.ClassKind }
+
+/*
+	The type of a rune (int32) parameter;  it reflects as int, but method
+	matching will also accept it for a declared char (see conversionCost).
+*/
+type runeType struct{}
+
+func (self runeType) TypeString() string { return types.Basic(types.IntKind).TypeString() }
+func (self runeType) Kind() types.Kind   { return types.IntKind }
+
 func TypeOf(env *Environment, v interface{}) (k types.Typed, err error) {
+	if v == nil {
+		return nullType{}, nil
+	}
 	if kind, ok := v.(types.Typed); ok {
 		return kind, nil
 	}
 	switch vt := v.(type) {
+	case int32:
+		return runeType{}, nil
 	case C.jstring:
 		return types.Class{types.JavaLangString}, nil
 	case types.Typed:
 		return vt, nil
+	case *NullObject:
+		return vt.Type, nil
 	case *Object:
+		if vt == nil || vt.object == nil {
+			return nullType{}, nil
+		}
 		name, err2 := vt.Name(env)
 		if err2 != nil {
 			err = err2
@@ -31,6 +59,8 @@ func TypeOf(env *Environment, v interface{}) (k types.Typed, err error) {
 		return types.Array{types.Class{vt.Name}}, nil
 	case *CastObject:
 		return types.Class{vt.Name}, nil
+	case *Class:
+		return types.Class{ClassClass}, nil
 	}
 
 	k, err = reflectedType(env, v)
@@ -47,8 +77,10 @@ func reflectedType(env *Environment, v interface{}) (k types.Typed, err error) {
 		k = types.Basic(types.BoolKind)
 	case reflect.Uint8, reflect.Int8:
 		k = types.Basic(types.ByteKind)
-	case reflect.Int16, reflect.Uint16:
+	case reflect.Int16:
 		k = types.Basic(types.ShortKind)
+	case reflect.Uint16:
+		k = types.Basic(types.CharKind)
 	case reflect.Int32, reflect.Uint32:
 		k = types.Basic(types.IntKind)
 	case reflect.Uint64, reflect.Int64:
@@ -66,8 +98,14 @@ func reflectedType(env *Environment, v interface{}) (k types.Typed, err error) {
 	case reflect.Slice, reflect.Array:
 		sltype := vtype.Elem()
 		switch sltype.Kind() {
-		case reflect.Uint8:
+		case reflect.Uint8, reflect.Int8:
 			k = types.Array{types.Basic(types.ByteKind)}
+		case reflect.Uint16:
+			k = types.Array{types.Basic(types.CharKind)}
+		case reflect.Int:
+			k = types.Array{types.Basic(types.IntKind)}
+		case reflect.Int64:
+			k = types.Array{types.Basic(types.LongKind)}
 		case reflect.String:
 			k = types.Array{types.Class{types.JavaLangString}}
 		default:
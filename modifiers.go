@@ -0,0 +1,57 @@
+package gojvm
+
+import (
+	"strings"
+)
+
+// The access & property flags of a class or member, as java.lang.reflect.Modifier
+// (and the class file format) define them.
+type Modifiers int
+
+const (
+	ModPublic       Modifiers = 0x0001
+	ModPrivate      Modifiers = 0x0002
+	ModProtected    Modifiers = 0x0004
+	ModStatic       Modifiers = 0x0008
+	ModFinal        Modifiers = 0x0010
+	ModSynchronized Modifiers = 0x0020
+	ModVolatile     Modifiers = 0x0040
+	ModTransient    Modifiers = 0x0080
+	ModNative       Modifiers = 0x0100
+	ModInterface    Modifiers = 0x0200
+	ModAbstract     Modifiers = 0x0400
+	ModStrict       Modifiers = 0x0800
+)
+
+// in the order Modifier.toString() lists them
+var modifierNames = []struct {
+	mod  Modifiers
+	name string
+}{
+	{ModPublic, "public"},
+	{ModProtected, "protected"},
+	{ModPrivate, "private"},
+	{ModAbstract, "abstract"},
+	{ModStatic, "static"},
+	{ModFinal, "final"},
+	{ModTransient, "transient"},
+	{ModVolatile, "volatile"},
+	{ModSynchronized, "synchronized"},
+	{ModNative, "native"},
+	{ModStrict, "strictfp"},
+	{ModInterface, "interface"},
+}
+
+// true if all of the flags in m are set
+func (self Modifiers) Has(m Modifiers) bool { return self&m == m }
+
+// the Java source form, e.g., "public static final"
+func (self Modifiers) String() string {
+	var words []string
+	for _, mn := range modifierNames {
+		if self.Has(mn.mod) {
+			words = append(words, mn.name)
+		}
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,368 @@
+package gojvm
+
+//#include "helpers.h"
+import "C"
+import (
+	"fmt"
+	"github.com/timob/gojvm/types"
+	"reflect"
+	"unsafe"
+)
+
+var javaLangRuntimeException = types.Name{"java", "lang", "RuntimeException"}
+
+// where a native argument was passed:  an integer or floating point register, or the stack
+const (
+	nativeInt = iota
+	nativeFP
+	nativeStack
+)
+
+type nativeArg struct {
+	area  int
+	index int
+}
+
+/*
+	Lays params out as the C calling convention passes them to a native (after
+	the JNIEnv and object);  ok is false if they don't fit the trampolines.
+*/
+func nativeLayout(params []types.Typed) (layout []nativeArg, ok bool) {
+	var ni, nf, ns int
+	for _, p := range params {
+		var arg nativeArg
+		switch k := p.Kind(); {
+		case (k == types.FloatKind || k == types.DoubleKind) && nf < C.NATIVE_FP_REGS:
+			arg = nativeArg{nativeFP, nf}
+			nf++
+		case k != types.FloatKind && k != types.DoubleKind && ni < C.NATIVE_INT_REGS:
+			arg = nativeArg{nativeInt, ni}
+			ni++
+		default:
+			arg = nativeArg{nativeStack, ns}
+			ns++
+		}
+		layout = append(layout, arg)
+	}
+	return layout, ns <= C.NATIVE_STACK
+}
+
+// a trampoline slot, and the method (of class, a global ref) it is bound to
+type nativeSlot struct {
+	class  *Class
+	method string
+	slot   int
+}
+
+/*
+	Binds the Go func f as the native 'method' (of signature sig) of class,
+	through a trampoline slot:  the method's own, if a Go native was bound to
+	it before, otherwise a free one (given back should the JVM refuse the
+	binding), so that re-registering a method doesn't use slots up.
+
+	f must be a func(*Environment, *Object, params...) with at most one return,
+	as for CallbackDescriptor;  if sig is empty, the reflected signature is
+	used, otherwise f must agree with it parameter for parameter (objects may
+	be taken as *Object, or string for java/lang/String).
+*/
+func (self *JVM) registerGoNative(env *Environment, class *Class, method string, sig types.MethodSignature, f interface{}) (err error) {
+	cbd, err := CallbackDescriptor(env, f)
+	if err != nil {
+		return
+	}
+	if sig.Return != nil {
+		if err = nativeConforms(sig, cbd.Signature); err != nil {
+			return
+		}
+		cbd.Signature = sig
+	}
+	if _, ok := nativeLayout(cbd.Signature.Params); !ok {
+		return ErrNativeArgs
+	}
+
+	self.nativeLock.Lock()
+	defer self.nativeLock.Unlock()
+	key := method + cbd.Signature.String()
+	bound := self.boundNative(env, class, key)
+	var slot int
+	if bound != nil {
+		slot = bound.slot
+	} else if slot, err = self.freeSlot(); err != nil {
+		return
+	}
+	if err = self.bindSlot(env, class, method, cbd, slot); err != nil {
+		if bound == nil {
+			self.freeSlots = append(self.freeSlots, slot)
+		}
+		return
+	}
+	if bound == nil {
+		self.natives = append(self.natives, nativeSlot{env.globalClass(class), key, slot})
+	}
+	return
+}
+
+// binds method of class to the trampoline of slot, which then calls cbd;  called with the nativeLock held
+func (self *JVM) bindSlot(env *Environment, class *Class, method string, cbd callbackDescriptor, slot int) (err error) {
+	fp := C.jboolean(C.JNI_FALSE)
+	if k := cbd.Signature.Return.Kind(); k == types.FloatKind || k == types.DoubleKind {
+		fp = C.JNI_TRUE
+	}
+	fptr := C.nativeTrampoline(C.int(slot), fp)
+	if fptr == nil {
+		return ErrNativeABI
+	}
+	if err = env.bindNative(class, method, cbd.Signature, fptr); err != nil {
+		return
+	}
+	self.lock.Lock()
+	self.registered[slot] = cbd
+	self.lock.Unlock()
+	return
+}
+
+// up to n free slots (fewer once they run out), for bindSlot;  give back any left unbound with returnSlots
+func (self *JVM) takeSlots(n int) (slots []int) {
+	self.nativeLock.Lock()
+	defer self.nativeLock.Unlock()
+	for len(slots) < n {
+		slot, err := self.freeSlot()
+		if err != nil {
+			break
+		}
+		slots = append(slots, slot)
+	}
+	return
+}
+
+// gives slots from takeSlots back
+func (self *JVM) returnSlots(slots []int) {
+	self.nativeLock.Lock()
+	defer self.nativeLock.Unlock()
+	self.freeSlots = append(self.freeSlots, slots...)
+}
+
+// forgets the Go natives bound to class (whose natives were unregistered), giving their slots back
+func (self *JVM) releaseNatives(env *Environment, class *Class) {
+	self.nativeLock.Lock()
+	defer self.nativeLock.Unlock()
+	kept := self.natives[:0]
+	for _, n := range self.natives {
+		if !env.IsSameObject(n.class.asObject(), class.asObject()) {
+			kept = append(kept, n)
+			continue
+		}
+		self.lock.Lock()
+		delete(self.registered, n.slot)
+		self.lock.Unlock()
+		self.freeSlots = append(self.freeSlots, n.slot)
+		env.DeleteGlobalClassRef(n.class)
+	}
+	self.natives = kept
+}
+
+// the slot of the method (name and descriptor) of class, if one is bound;  called with the nativeLock held
+func (self *JVM) boundNative(env *Environment, class *Class, method string) *nativeSlot {
+	for i, n := range self.natives {
+		if n.method == method && env.IsSameObject(n.class.asObject(), class.asObject()) {
+			return &self.natives[i]
+		}
+	}
+	return nil
+}
+
+// a slot no method is bound to;  called with the nativeLock held
+func (self *JVM) freeSlot() (slot int, err error) {
+	if n := len(self.freeSlots); n > 0 {
+		slot = self.freeSlots[n-1]
+		self.freeSlots = self.freeSlots[:n-1]
+		return
+	}
+	if self.regId >= C.NATIVE_SLOTS {
+		return 0, ErrNativeSlots
+	}
+	slot = self.regId
+	self.regId++
+	return
+}
+
+// the callback registered for slot
+func (self *JVM) native(slot int) (cbd callbackDescriptor, ok bool) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	cbd, ok = self.registered[slot]
+	return
+}
+
+// checks that a reflected callback signature can implement the declared one
+func nativeConforms(declared, reflected types.MethodSignature) error {
+	if len(declared.Params) != len(reflected.Params) {
+		return ErrArgCount
+	}
+	for i, p := range declared.Params {
+		if !kindConforms(p, reflected.Params[i]) {
+			return ErrWrongKind
+		}
+	}
+	dk, rk := declared.Return.Kind(), reflected.Return.Kind()
+	if dk == types.VoidKind || rk == types.VoidKind {
+		if dk != rk {
+			return ErrWrongKind
+		}
+		return nil
+	}
+	if !kindConforms(declared.Return, reflected.Return) {
+		return ErrWrongKind
+	}
+	return nil
+}
+
+// primitives must match exactly;  any reference type may stand for another
+func kindConforms(declared, reflected types.Typed) bool {
+	dk, rk := declared.Kind(), reflected.Kind()
+	dref := dk == types.ClassKind || dk == types.ArrayKind
+	rref := rk == types.ClassKind || rk == types.ArrayKind
+	if dref || rref {
+		return dref && rref
+	}
+	return dk == rk
+}
+
+/*
+	Every Go native call comes through here, from its trampoline in
+	jvm_natives.c;  args holds the raw argument registers and stack, which
+	are decoded according to the signature registered for slot.  The
+	result (if any) is left in args.ret.
+
+	Errors and panics in the callback are thrown back to Java as
+	java/lang/RuntimeException, as a Go panic must not unwind through the
+	JVM's frames.
+*/
+//export goNative
+func goNative(slot C.int, envp *C.JNIEnv, obj C.jobject, args *C.NativeArgs) {
+	env, added, err := envFor(envp)
+	if err != nil {
+		return
+	}
+	if added {
+		defer forgetEnv(env)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			env.ThrowNew(javaLangRuntimeException, fmt.Sprint("Go native panicked: ", r))
+		}
+	}()
+	cbd, ok := env.jvm.native(int(slot))
+	if !ok {
+		env.ThrowNew(javaLangRuntimeException, fmt.Sprint("No Go native registered in slot ", slot))
+		return
+	}
+	in, err := env.nativeParams(cbd, args)
+	if err != nil {
+		env.ThrowNew(javaLangRuntimeException, err.Error())
+		return
+	}
+	out := reflect.ValueOf(cbd.F).Call(append([]reflect.Value{reflect.ValueOf(env), reflect.ValueOf(newObject(obj))}, in...))
+	if len(out) == 1 && cbd.Signature.Return.Kind() != types.VoidKind {
+		if args.ret, err = env.nativeReturn(cbd.Signature.Return, out[0]); err != nil {
+			env.ThrowNew(javaLangRuntimeException, err.Error())
+		}
+	}
+}
+
+// decodes the parameters of a native call into the Go types the callback declares
+func (self *Environment) nativeParams(cbd callbackDescriptor, args *C.NativeArgs) (in []reflect.Value, err error) {
+	layout, _ := nativeLayout(cbd.Signature.Params)
+	for i, p := range cbd.Signature.Params {
+		var slot unsafe.Pointer
+		switch a := layout[i]; a.area {
+		case nativeInt:
+			slot = unsafe.Pointer(&args.ints[a.index])
+		case nativeFP:
+			slot = unsafe.Pointer(&args.fps[a.index])
+		default:
+			slot = unsafe.Pointer(&args.stack[a.index])
+		}
+		var v reflect.Value
+		if v, err = self.nativeValue(p, cbd.PTypes[i], slot); err != nil {
+			return
+		}
+		in = append(in, v)
+	}
+	return
+}
+
+/*
+	Reads a value of Java type t from a spilled register or stack slot (the
+	value sits in its low bytes), as Go type pt.
+*/
+func (self *Environment) nativeValue(t types.Typed, pt reflect.Type, slot unsafe.Pointer) (v reflect.Value, err error) {
+	switch t.Kind() {
+	case types.BoolKind:
+		v = reflect.ValueOf(*(*C.jboolean)(slot) != C.JNI_FALSE)
+	case types.ByteKind:
+		v = reflect.ValueOf(int8(*(*C.jbyte)(slot)))
+	case types.CharKind:
+		v = reflect.ValueOf(uint16(*(*C.jchar)(slot)))
+	case types.ShortKind:
+		v = reflect.ValueOf(int16(*(*C.jshort)(slot)))
+	case types.IntKind:
+		v = reflect.ValueOf(int32(*(*C.jint)(slot)))
+	case types.LongKind:
+		v = reflect.ValueOf(int64(*(*C.jlong)(slot)))
+	case types.FloatKind:
+		v = reflect.ValueOf(float32(*(*C.jfloat)(slot)))
+	case types.DoubleKind:
+		v = reflect.ValueOf(float64(*(*C.jdouble)(slot)))
+	default:
+		obj := newObject(*(*C.jobject)(slot))
+		if pt.Kind() != reflect.String {
+			return reflect.ValueOf(obj), nil
+		}
+		var s string
+		if s, _, err = self.ToString(obj); err != nil {
+			return
+		}
+		v = reflect.ValueOf(s)
+	}
+	if v.Type() != pt {
+		if !v.Type().ConvertibleTo(pt) {
+			return v, ErrWrongKind
+		}
+		v = v.Convert(pt)
+	}
+	return
+}
+
+// encodes the value returned by a callback as the Java type t
+func (self *Environment) nativeReturn(t types.Typed, out reflect.Value) (jv C.jvalue, err error) {
+	switch t.Kind() {
+	case types.ClassKind, types.ArrayKind:
+		switch v := out.Interface().(type) {
+		case string:
+			var obj *Object
+			if obj, err = self.localString(v); err == nil {
+				jv = C.objValue(obj.object)
+			}
+		case *Object:
+			if v != nil {
+				jv = C.objValue(v.object)
+			}
+		default:
+			err = ErrWrongKind
+		}
+		return
+	}
+	return primitiveValue(t.Kind(), out.Interface())
+}
+
+// s as a new java/lang/String local ref, as natives return them (NewStringObject's is global)
+func (self *Environment) localString(s string) (obj *Object, err error) {
+	g, err := self.NewStringObject(s)
+	if err != nil {
+		return
+	}
+	obj = self.NewLocalRef(g)
+	self.DeleteGlobalRef(g)
+	return
+}
@@ -1,12 +1,12 @@
 package gojvm
 
 //#cgo CFLAGS:-I../include/
-//#cgo LDFLAGS:-ljvm	-L/usr/lib/jvm/java-6-sun/jre/lib/amd64/server/
 //#include "helpers.h"
 import "C"
 import (
 	"github.com/timob/gojvm/types"
 //	"log"
+	"strings"
     "unsafe"
 )
 
@@ -81,6 +81,15 @@ func (self *Object) CallFloat(env *Environment, static bool, mname string, param
 	return env.CallObjectFloat(self, static, mname, params...)
 }
 
+func (self *Object) CallByte(env *Environment, static bool, mname string, params ...interface{}) (i int8, err error) {
+	return env.CallObjectByte(self, static, mname, params...)
+}
+
+// Java chars are returned as (UTF-16) uint16s
+func (self *Object) CallChar(env *Environment, static bool, mname string, params ...interface{}) (i uint16, err error) {
+	return env.CallObjectChar(self, static, mname, params...)
+}
+
 func (self *Object) CallShort(env *Environment, static bool, mname string, params ...interface{}) (i int16, err error) {
 	return env.CallObjectShort(self, static, mname, params...)
 }
@@ -97,6 +106,24 @@ func (self *Object) CallIntArray(env *Environment, static bool, mname string, pa
 	return env.CallObjectIntArray(self, static, mname, params...)
 }
 
+func (self *Object) CallByteArray(env *Environment, static bool, mname string, params ...interface{}) (i []int8, err error) {
+	return env.CallObjectByteArray(self, static, mname, params...)
+}
+
+func (self *Object) CallCharArray(env *Environment, static bool, mname string, params ...interface{}) (i []uint16, err error) {
+	return env.CallObjectCharArray(self, static, mname, params...)
+}
+
+// Calls the named method, returning the result as a Go value (see Environment.Call)
+func (self *Object) Call(env *Environment, static bool, mname string, rval types.Typed, params ...interface{}) (v interface{}, err error) {
+	return env.Call(self, static, mname, rval, params...)
+}
+
+// Calls class's implementation of the named method on the object (see Environment.CallNonvirtual)
+func (self *Object) CallNonvirtual(env *Environment, class *Class, mname string, rval types.Typed, params ...interface{}) (v interface{}, err error) {
+	return env.CallNonvirtual(self, class, mname, rval, params...)
+}
+
 // Calls the named Object-method on the object instance
 func (self *Object) CallObj(env *Environment, static bool, mname string, rval types.Typed, params ...interface{}) (vObj *Object, err error) {
 	return env.CallObjectObj(self, static, mname, rval, params...)
@@ -116,6 +143,28 @@ type CastObject struct {
 	types.Name
 }
 
+/*
+	A typed Java null, for passing null where the parameter type matters
+	(e.g., choosing between overloads);  an untyped nil parameter is also
+	accepted, and is matched against whatever object type the method declares.
+*/
+type NullObject struct {
+	Type types.Typed
+}
+
+/*
+	Returns a typed null for the named class (e.g., "java/lang/String");  array
+	types may be given as descriptors (e.g., "[I" or "[Ljava/lang/String;").
+*/
+func Null(klass string) *NullObject {
+	if strings.HasPrefix(klass, "[") {
+		if t, err := types.ParseTypeString(klass); err == nil {
+			return &NullObject{t}
+		}
+	}
+	return &NullObject{types.Class{types.NewName(klass)}}
+}
+
 type ObjectArray struct {
 	Objects []*Object
 	types.Name
@@ -138,6 +187,14 @@ func (self *Object) GetBooleanField(env *Environment, static bool, name string)
     return env.GetObjectBooleanField(self, static, name)
 }
 
+func (self *Object) GetByteField(env *Environment, static bool, name string) (int8, error) {
+    return env.GetObjectByteField(self, static, name)
+}
+
+func (self *Object) GetCharField(env *Environment, static bool, name string) (uint16, error) {
+    return env.GetObjectCharField(self, static, name)
+}
+
 func (self *Object) GetShortField(env *Environment, static bool, name string) (int16, error) {
     return env.GetObjectShortField(self, static, name)
 }
@@ -162,6 +219,14 @@ func (self *Object) GetIntArrayField(env *Environment, static bool, name string)
     return env.GetObjecIntArrayField(self, static, name)
 }
 
+func (self *Object) GetByteArrayField(env *Environment, static bool, name string) ([]int8, error) {
+    return env.GetObjectByteArrayField(self, static, name)
+}
+
+func (self *Object) GetCharArrayField(env *Environment, static bool, name string) ([]uint16, error) {
+    return env.GetObjectCharArrayField(self, static, name)
+}
+
 
 // ----
 
@@ -174,6 +239,14 @@ func (self *Object) SetBooleanField(env *Environment, static bool, name string,
     return env.setBoolField(self, static, name, val)
 }
 
+func (self *Object) SetByteField(env *Environment, static bool, name string, val int8) (err error) {
+    return env.setByteField(self, static, name, val)
+}
+
+func (self *Object) SetCharField(env *Environment, static bool, name string, val uint16) (err error) {
+    return env.setCharField(self, static, name, val)
+}
+
 func (self *Object) SetShortField(env *Environment, static bool, name string, val int16) (err error) {
     return env.setShortField(self, static, name, val)
 }
@@ -193,3 +266,8 @@ func (self *Object) SetFloatField(env *Environment, static bool, name string, va
 func (self *Object) SetDoubleField(env *Environment, static bool, name string, val float64) (err error) {
     return env.setDoubleField(self, static, name, val)
 }
+
+// Sets an array field from a Go slice or *ObjectArray (see Class.SetArrayField)
+func (self *Object) SetArrayField(env *Environment, static bool, name string, val interface{}) (err error) {
+    return env.setArrayField(self, static, name, val)
+}
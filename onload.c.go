@@ -0,0 +1,86 @@
+package gojvm
+
+//#include "helpers.h"
+import "C"
+import (
+	"github.com/timob/gojvm/types"
+	"sync"
+	"unsafe"
+)
+
+var unsatisfiedLinkError = types.Name{"java", "lang", "UnsatisfiedLinkError"}
+
+/*
+	When Go is built as a c-shared library (with -tags gojvm_onload) and
+	loaded by Java with System.loadLibrary, the JNI_OnLoad of jvm_onload.c
+	adopts the calling JVM and runs the OnLoad hooks;  this is where the
+	library registers its natives:
+
+		func init() {
+			gojvm.OnLoad(func(jvm *gojvm.JVM, env *gojvm.Environment) error {
+				return env.RegisterNative("org/example/Lib", "sum", types.MethodSignature{},
+					func(env *gojvm.Environment, cls *gojvm.Object, a, b int) int { return a + b })
+			})
+		}
+*/
+var loadHooks struct {
+	sync.Mutex
+	onLoad   []func(*JVM, *Environment) error
+	onUnload []func(*JVM)
+}
+
+/*
+	Adds f to the hooks run (in order) by JNI_OnLoad, on the thread loading
+	the library;  an error from any of them fails the load (with an
+	UnsatisfiedLinkError of the error's message in Java).  Hooks should be added from init.
+*/
+func OnLoad(f func(jvm *JVM, env *Environment) error) {
+	loadHooks.Lock()
+	defer loadHooks.Unlock()
+	loadHooks.onLoad = append(loadHooks.onLoad, f)
+}
+
+/*
+	Adds f to the hooks run by JNI_OnUnload, when the class loader of the
+	library is collected;  there is no usable Environment at that point.
+*/
+func OnUnload(f func(jvm *JVM)) {
+	loadHooks.Lock()
+	defer loadHooks.Unlock()
+	loadHooks.onUnload = append(loadHooks.onUnload, f)
+}
+
+//export goJNIOnLoad
+func goJNIOnLoad(vm *C.JavaVM, envp *C.JNIEnv) C.jint {
+	env, added, err := envFor(envp)
+	if err != nil {
+		return C.JNI_ERR
+	}
+	if added {
+		defer forgetEnv(env)
+	}
+	loadHooks.Lock()
+	hooks := loadHooks.onLoad
+	loadHooks.Unlock()
+	for _, f := range hooks {
+		if err = f(env.jvm, env); err != nil {
+			env.ThrowNew(unsatisfiedLinkError, "gojvm: JNI_OnLoad hook failed: "+err.Error())
+			return C.JNI_ERR
+		}
+	}
+	return C.jint(DEFAULT_JVM_VERSION)
+}
+
+//export goJNIOnUnload
+func goJNIOnUnload(vm *C.JavaVM) {
+	jvm := AllVMs.Find(uintptr(unsafe.Pointer(vm)))
+	if jvm == nil {
+		return
+	}
+	loadHooks.Lock()
+	hooks := loadHooks.onUnload
+	loadHooks.Unlock()
+	for _, f := range hooks {
+		f(jvm)
+	}
+}
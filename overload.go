@@ -0,0 +1,217 @@
+package gojvm
+
+import (
+	"errors"
+	"github.com/timob/gojvm/types"
+)
+
+var reflectMethodClass = types.Name{"java", "lang", "reflect", "Method"}
+var reflectConstructorClass = types.Name{"java", "lang", "reflect", "Constructor"}
+
+// no candidate could accept the parameters
+var errNoOverload = errors.New("No overload matches the given parameters")
+
+/*
+	Used when the JVM has no method matching the exact (reflected) signature of
+	params;  walks the methods (or constructors, for "<init>") of class via
+	java.lang.reflect, and returns the signature of the best candidate that the
+	parameters can reach through boxing, unboxing or reference widening.
+
+	Candidates that need the fewest conversions win;  ties go to the first found.
+*/
+func (self *Environment) matchMethod(class *Class, static bool, name string, rtype types.Typed, params ...interface{}) (sig types.MethodSignature, err error) {
+	ptypes, err := ParameterTypes(self, params...)
+	if err != nil {
+		return
+	}
+	var lists []string
+	var elem types.Typed
+	if name == "<init>" {
+		lists, elem = []string{"getDeclaredConstructors"}, types.Class{reflectConstructorClass}
+	} else {
+		lists, elem = []string{"getMethods", "getDeclaredMethods"}, types.Class{reflectMethodClass}
+	}
+	best := -1
+	for _, list := range lists {
+		var arr *Object
+		arr, err = class.asObject().CallObj(self, false, list, types.Array{elem})
+		if err != nil {
+			return
+		}
+		for _, cand := range self.ToObjectArray(arr) {
+			csig, score, cerr := self.scoreCandidate(cand, static, name, rtype, ptypes)
+			self.DeleteLocalRef(cand)
+			if cerr == nil && (best < 0 || score < best) {
+				sig, best = csig, score
+			}
+		}
+		self.DeleteLocalRef(arr)
+	}
+	if best < 0 {
+		err = errNoOverload
+	}
+	return
+}
+
+// Returns the declared signature of a reflected method/constructor, and the number of
+// conversions needed to call it with arguments of ptypes (or errNoOverload).
+func (self *Environment) scoreCandidate(cand *Object, static bool, name string, rtype types.Typed, ptypes []types.Typed) (sig types.MethodSignature, score int, err error) {
+	sig.Return = types.Basic(types.VoidKind)
+	if name != "<init>" {
+		var mname string
+		var mods int
+		mname, _, err = cand.CallString(self, false, "getName")
+		if err == nil && mname != name {
+			err = errNoOverload
+		}
+		if err == nil {
+			mods, err = cand.CallInt(self, false, "getModifiers")
+		}
+		if err == nil && Modifiers(mods).Has(ModStatic) != static {
+			err = errNoOverload
+		}
+		if err == nil {
+			sig.Return, err = self.reflectedClassType(cand, "getReturnType")
+		}
+		if err == nil && !self.returnCompatible(rtype, sig.Return) {
+			err = errNoOverload
+		}
+		if err != nil {
+			return
+		}
+	}
+	arr, err := cand.CallObj(self, false, "getParameterTypes", types.Array{types.Class{ClassClass}})
+	if err != nil {
+		return
+	}
+	defer self.DeleteLocalRef(arr)
+	pclasses := self.ToObjectArray(arr)
+	defer blowStack(self, pclasses)
+	if len(pclasses) != len(ptypes) {
+		err = errNoOverload
+		return
+	}
+	for i, pclass := range pclasses {
+		var declared types.Typed
+		var cost int
+		declared, err = self.classObjectType(pclass)
+		if err == nil {
+			cost, err = self.conversionCost(ptypes[i], declared)
+		}
+		if err != nil {
+			return
+		}
+		sig.Params = append(sig.Params, declared)
+		score += cost
+	}
+	return
+}
+
+// the number of conversions (0 or 1) needed to pass a value of type 'arg' as 'declared'
+func (self *Environment) conversionCost(arg, declared types.Typed) (cost int, err error) {
+	if arg.TypeString() == declared.TypeString() {
+		return 0, nil
+	}
+	if _, ok := arg.(nullType); ok {
+		if k := declared.Kind(); k == types.ClassKind || k == types.ArrayKind {
+			return 1, nil
+		}
+		return 0, errNoOverload
+	}
+	if _, ok := arg.(runeType); ok && declared.Kind() == types.CharKind {
+		return 1, nil
+	}
+	dclass, dIsClass := declared.(types.Class)
+	aclass, aIsClass := arg.(types.Class)
+	switch {
+	case dIsClass && !aIsClass:
+		// boxing;  the wrapper itself, or anything it is assignable to (Object, Number, ...)
+		wrapper, ok := types.BoxedName(arg.Kind())
+		if ok && self.assignable(dclass.Klass, wrapper) {
+			return 1, nil
+		}
+	case aIsClass && !dIsClass:
+		if k, ok := types.UnboxedKind(aclass.Klass); ok && k == declared.Kind() {
+			return 1, nil
+		}
+	case aIsClass && dIsClass:
+		if self.assignable(dclass.Klass, aclass.Klass) {
+			return 1, nil
+		}
+	}
+	return 0, errNoOverload
+}
+
+// true if a method asked to return 'want' may be satisfied by one declared to return 'have'
+func (self *Environment) returnCompatible(want, have types.Typed) bool {
+	if want.TypeString() == have.TypeString() {
+		return true
+	}
+	hclass, ok := have.(types.Class)
+	if !ok {
+		return false
+	}
+	if wclass, ok := want.(types.Class); ok {
+		return self.assignable(wclass.Klass, hclass.Klass)
+	}
+	wrapper, ok := types.BoxedName(want.Kind())
+	return ok && wrapper.AsPath() == hclass.Klass.AsPath()
+}
+
+// true if the class 'to' is assignable from (a super-type of) 'from'
+func (self *Environment) assignable(to, from types.Name) bool {
+	if to.AsPath() == from.AsPath() {
+		return true
+	}
+	tc, err := self.GetClass(to)
+	if err != nil {
+		return false
+	}
+	fc, err := self.GetClass(from)
+	if err != nil {
+		return false
+	}
+	return self.IsAssignableFrom(tc, fc)
+}
+
+// calls the named niladic Class-returning method on obj, and returns the type it represents
+func (self *Environment) reflectedClassType(obj *Object, mname string) (t types.Typed, err error) {
+	cobj, err := obj.CallObj(self, false, mname, types.Class{ClassClass})
+	if err != nil {
+		return
+	}
+	defer self.DeleteLocalRef(cobj)
+	return self.classObjectType(cobj)
+}
+
+// returns the type represented by a java/lang/Class object (which may be a primitive or array class)
+func (self *Environment) classObjectType(cobj *Object) (t types.Typed, err error) {
+	cname, _, err := cobj.CallString(self, false, "getName")
+	if err != nil {
+		return
+	}
+	return javaNameType(cname)
+}
+
+var primitiveNames = map[string]types.Kind{
+	"boolean": types.BoolKind,
+	"byte":    types.ByteKind,
+	"char":    types.CharKind,
+	"short":   types.ShortKind,
+	"int":     types.IntKind,
+	"long":    types.LongKind,
+	"float":   types.FloatKind,
+	"double":  types.DoubleKind,
+	"void":    types.VoidKind,
+}
+
+// converts the result of Class.getName() ("int", "java.lang.String", "[J", ...) into a Typed
+func javaNameType(cname string) (types.Typed, error) {
+	if k, ok := primitiveNames[cname]; ok {
+		return types.Basic(k), nil
+	}
+	if len(cname) > 0 && cname[0] == '[' {
+		return types.ParseTypeString(cname)
+	}
+	return types.Class{types.NewName(cname)}, nil
+}
@@ -0,0 +1,93 @@
+package gojvm
+
+import (
+	"github.com/timob/gojvm/types"
+	"github.com/timob/gojvm/types/classfile"
+)
+
+var shutdownHookClass = types.Name{"org", "golang", "ext", "gojvm", "ShutdownHook"}
+var javaLangRuntime = types.Name{"java", "lang", "Runtime"}
+
+/*
+	Registers f to run when the JVM shuts down, whether by Destroy, by the
+	last non-daemon thread ending, or by System.exit in Java.  f runs on a
+	Java thread of its own, with that thread's Environment;  as for any Java
+	shutdown hooks, hooks run concurrently, in no particular order.
+
+	(A Go process that exits without Destroy does not shut the JVM down, and
+	so runs no hooks.)  As for AddClassPath, a goroutine whose thread isn't
+	attached is attached for the call only.
+*/
+func (self *JVM) AddShutdownHook(f func(env *Environment)) (err error) {
+	env, attached, err := self.currentEnv()
+	if err != nil {
+		return
+	}
+	defer self.doneEnv(attached)
+	err = self.defineGoClass(env, shutdownHookClass, shutdownHookBytes(), goMethod{
+		"run", types.MethodSignature{Params: []types.Typed{}, Return: types.Basic(types.VoidKind)}, self.runShutdownHook,
+	})
+	if err != nil {
+		return
+	}
+	class, err := env.GetClass(shutdownHookClass)
+	if err != nil {
+		return
+	}
+	hook, err := env.NewInstance(class)
+	if err != nil {
+		return
+	}
+	defer env.DeleteGlobalRef(hook)
+	id := self.keep(f)
+	defer func() {
+		if err != nil {
+			self.unkeep(id)
+		}
+	}()
+	if err = hook.SetLongField(env, false, "id", id); err != nil {
+		return
+	}
+	thread, err := env.Cast(hook, threadClass)
+	if err != nil {
+		return
+	}
+	rclass, err := env.GetClass(javaLangRuntime)
+	if err != nil {
+		return
+	}
+	rt, err := rclass.CallObj(env, true, "getRuntime", types.Class{javaLangRuntime})
+	if err != nil {
+		return
+	}
+	defer env.DeleteLocalRef(rt)
+	return rt.CallVoid(env, false, "addShutdownHook", thread)
+}
+
+// the native ShutdownHook.run:  calls the hook its id names
+func (self *JVM) runShutdownHook(env *Environment, hook *Object) {
+	id, err := hook.GetLongField(env, false, "id")
+	if err != nil {
+		panic(err)
+	}
+	if f, ok := self.keptValue(id).(func(*Environment)); ok {
+		f(env)
+	}
+}
+
+/*
+	assembles:
+
+		public final class org.golang.ext.gojvm.ShutdownHook extends Thread {
+			public long id;
+			public ShutdownHook() { super(); }
+			public native void run();
+		}
+*/
+func shutdownHookBytes() []byte {
+	a := classfile.NewWriter()
+	a.Field(classfile.AccPublic, "id", "J")
+	a.DefaultConstructor(threadClass.AsPath())
+	a.NativeMethod(classfile.AccPublic|classfile.AccNative, "run", "()V")
+	return a.Bytes(classfile.AccPublic|classfile.AccFinal|classfile.AccSuper, shutdownHookClass.AsPath(), threadClass.AsPath())
+}
@@ -0,0 +1,350 @@
+package gojvm
+
+import (
+	"fmt"
+	"github.com/timob/gojvm/types"
+	"github.com/timob/gojvm/types/classfile"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// the package of the classes Subclass defines
+const subclassPackage = "org/golang/ext/gojvm/sub/"
+
+var goOverrideClass = types.Name{"org", "golang", "ext", "gojvm", "GoOverride"}
+
+// GoOverride.invoke(long, int, Object, Object[])
+var overrideSig = types.MethodSignature{
+	Params: []types.Typed{
+		types.Basic(types.LongKind),
+		types.Basic(types.IntKind),
+		types.Class{types.JavaLangObject},
+		objectArray,
+	},
+	Return: types.Class{types.JavaLangObject},
+}
+
+// the static field of a subclass holding the id its Go funcs are kept as
+const overridesField = "goOverrides"
+
+// the Go funcs of a subclass's overrides, by the index its methods pass
+type goOverrides struct {
+	methods []callbackDescriptor
+}
+
+/*
+	Defines a new (final) subclass of the class base, whose methods named by
+	overrides call the Go funcs, which take (and return) what a Go native
+	of the method would (see RegisterNative):
+
+		task, err := env.Subclass("java/util/TimerTask", map[string]interface{}{
+			"run": func(env *Environment, this *Object) { ... },
+		})
+		...
+		obj, err := env.NewInstance(task)
+
+	A key is either a method name, to override each overload (public or
+	protected, and not final) that the func fits, or a name and descriptor
+	("read([BII)I") to override just that one;  the latter wins where both
+	name a method.  The subclass has a public constructor for each public or
+	protected constructor of base, passing its arguments on.
+
+	The overrides are native methods bound to their Go funcs, as long as
+	native slots last (see RegisterNative);  past that (or for methods with
+	more arguments than a native can take) they call their funcs through a
+	single Go native, GoOverride.invoke, with boxed arguments.
+
+	Each call defines a new class (in base's class loader), which keeps its
+	Go funcs (and slots) until Unsubclass;  so define a subclass once, and
+	make as many instances of it as needed.  The result is a global ref,
+	owned by the caller (release it with DeleteGlobalClassRef).
+*/
+func (self *Environment) Subclass(base string, overrides map[string]interface{}) (class *Class, err error) {
+	bclass, err := self.GetClassStr(base)
+	if err != nil {
+		return
+	}
+	info, err := self.Describe(bclass)
+	if err != nil {
+		return
+	}
+	defer info.Release(self)
+	if info.Modifiers.Has(ModFinal) || info.Modifiers.Has(ModInterface) {
+		return nil, ErrNotSubclassable
+	}
+	methods, err := self.overrides(info, overrides)
+	if err != nil {
+		return
+	}
+	err = self.jvm.defineGoClass(self, goOverrideClass, goOverrideBytes(), goMethod{"invoke", overrideSig, self.jvm.invokeOverride})
+	if err != nil {
+		return
+	}
+
+	self.jvm.defineLock.Lock()
+	self.jvm.subclasses++
+	name := types.NewName(fmt.Sprintf("%s%s$%d", subclassPackage,
+		strings.Replace(info.Name.AsPath(), "/", "_", -1), self.jvm.subclasses))
+	self.jvm.defineLock.Unlock()
+
+	a := classfile.NewWriter()
+	for _, ctor := range info.Constructors {
+		if ctor.Modifiers&(ModPublic|ModProtected) != 0 {
+			a.SuperConstructor(info.Name.AsPath(), ctor.Signature.Params)
+		}
+	}
+	subclass := &goOverrides{}
+	id := self.jvm.keep(subclass)
+	a.ConstantField(classfile.AccPrivate|classfile.AccStatic|classfile.AccFinal, overridesField, "J", a.Number(id))
+	natives := 0
+	for _, m := range methods {
+		if _, ok := nativeLayout(m.sig.Params); ok {
+			natives++
+		}
+	}
+	slots := self.jvm.takeSlots(natives)
+	bound := []goMethod{}
+	for i, m := range methods {
+		cbd, _ := CallbackDescriptor(self, m.f) // (as overrides checked)
+		cbd.Signature = m.sig
+		subclass.methods = append(subclass.methods, cbd)
+		if _, ok := nativeLayout(m.sig.Params); ok && len(bound) < len(slots) {
+			bound = append(bound, m)
+			a.NativeMethod(classfile.AccPublic|classfile.AccNative, m.name, m.sig.String())
+			continue
+		}
+		code, maxLocals := overrideCode(a, id, i, m.sig)
+		a.Method(classfile.AccPublic, m.name, m.sig.String(), overrideStack, maxLocals, code)
+	}
+	loader, err := self.loaderOf(bclass)
+	if err == nil {
+		if loader != nil {
+			defer self.DeleteLocalRef(loader)
+		}
+		class, err = self.DefineClass(name, loader, a.Bytes(classfile.AccPublic|classfile.AccFinal|classfile.AccSuper, name.AsPath(), info.Name.AsPath()))
+	}
+	if err != nil {
+		self.jvm.returnSlots(slots)
+		self.jvm.unkeep(id)
+		return nil, err
+	}
+	if err = self.jvm.bindOverrides(self, class, bound, slots); err != nil {
+		self.Unsubclass(class)
+		self.DeleteGlobalClassRef(class)
+		return nil, err
+	}
+	return
+}
+
+/*
+	Releases the Go funcs and native slots behind a class from Subclass (the
+	class itself must still be released with DeleteGlobalClassRef);  calls to
+	its overrides fail afterwards.
+*/
+func (self *Environment) Unsubclass(class *Class) (err error) {
+	id, err := class.GetLongField(self, true, overridesField)
+	if err != nil {
+		return
+	}
+	self.jvm.unkeep(id)
+	return self.UnregisterNatives(class)
+}
+
+// binds the native overrides of class to their Go funcs, through slots (from takeSlots);  slots left unbound are given back
+func (self *JVM) bindOverrides(env *Environment, class *Class, methods []goMethod, slots []int) (err error) {
+	self.nativeLock.Lock()
+	defer self.nativeLock.Unlock()
+	for i, slot := range slots {
+		if err == nil && i < len(methods) {
+			m := methods[i]
+			cbd, _ := CallbackDescriptor(env, m.f)
+			cbd.Signature = m.sig
+			if err = self.bindSlot(env, class, m.name, cbd, slot); err == nil {
+				self.natives = append(self.natives, nativeSlot{env.globalClass(class), m.name + m.sig.String(), slot})
+				continue
+			}
+		}
+		self.freeSlots = append(self.freeSlots, slot)
+	}
+	return
+}
+
+// the native GoOverride.invoke:  calls the override (of index) of the subclass id names, on this
+func (self *JVM) invokeOverride(env *Environment, cls *Object, id int64, index int, this, args *Object) *Object {
+	subclass, ok := self.keptValue(id).(*goOverrides)
+	if !ok || index < 0 || index >= len(subclass.methods) {
+		panic("invocation of an unknown Go override")
+	}
+	cbd := subclass.methods[index]
+	params := env.ToObjectArray(args)
+	defer blowStack(env, params)
+	in := []reflect.Value{reflect.ValueOf(env), reflect.ValueOf(this)}
+	for i, p := range cbd.Signature.Params {
+		v, err := env.unboxedParam(p, cbd.PTypes[i], params[i])
+		if err != nil {
+			panic(err)
+		}
+		in = append(in, v)
+	}
+	out := reflect.ValueOf(cbd.F).Call(in)
+	if len(out) == 0 || cbd.Signature.Return.Kind() == types.VoidKind {
+		return nil
+	}
+	result, err := env.boxedResult(out[0])
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+// the methods of info that overrides (see Subclass) override, and their Go funcs
+func (self *Environment) overrides(info *ClassInfo, overrides map[string]interface{}) (methods []goMethod, err error) {
+	// descriptor keys first, so they take their methods from name keys
+	keys := []string{}
+	for key := range overrides {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		ki, kj := strings.Contains(keys[i], "("), strings.Contains(keys[j], "(")
+		if ki != kj {
+			return ki
+		}
+		return keys[i] < keys[j]
+	})
+
+	overridden := map[string]bool{}
+	for _, key := range keys {
+		cbd, err := CallbackDescriptor(self, overrides[key])
+		if err != nil {
+			return nil, err
+		}
+		matched := false
+		for _, mi := range info.Methods {
+			method := mi.Name + mi.Signature.String()
+			if (key != mi.Name && key != method) || !overridable(mi.Modifiers) {
+				continue
+			}
+			if nativeConforms(mi.Signature, cbd.Signature) != nil {
+				continue
+			}
+			matched = true
+			if !overridden[method] {
+				overridden[method] = true
+				methods = append(methods, goMethod{mi.Name, mi.Signature, overrides[key]})
+			}
+		}
+		if !matched {
+			return nil, ErrNoOverride
+		}
+	}
+	return
+}
+
+// true for the methods a subclass in another package may override
+func overridable(mods Modifiers) bool {
+	if mods&(ModStatic|ModFinal|ModPrivate) != 0 {
+		return false
+	}
+	return mods&(ModPublic|ModProtected) != 0
+}
+
+// the operand stack an override needs:  id (a long), index, this, the argument array (and its copy), an index and a long
+const overrideStack = 9
+
+// the primitive accessor of each wrapper (intValue ...)
+var unboxMethods = map[types.Kind]string{
+	types.BoolKind:   "booleanValue",
+	types.ByteKind:   "byteValue",
+	types.CharKind:   "charValue",
+	types.ShortKind:  "shortValue",
+	types.IntKind:    "intValue",
+	types.LongKind:   "longValue",
+	types.FloatKind:  "floatValue",
+	types.DoubleKind: "doubleValue",
+}
+
+/*
+	The code of the override (of index) of signature sig, of the subclass
+	whose overrides are kept as id, in the class a writes:
+
+		return (R)GoOverride.invoke(id, index, this, new Object[]{ params... });
+
+	with primitive parameters boxed, and a primitive result unboxed.
+*/
+func overrideCode(a *classfile.Writer, id int64, index int, sig types.MethodSignature) (code []byte, maxLocals uint16) {
+	op := func(op byte, i uint16) {
+		code = append(code, op, byte(i>>8), byte(i))
+	}
+	op(0x14, a.Number(id))           // ldc2_w
+	op(0x13, a.Number(int32(index))) // ldc_w
+	code = append(code, 0x2a)        // aload_0
+	op(0x13, a.Number(int32(len(sig.Params))))
+	op(0xbd, a.Class(types.JavaLangObject.AsPath())) // anewarray
+	slot := 1
+	for i, p := range sig.Params {
+		code = append(code, 0x59) // dup
+		op(0x13, a.Number(int32(i)))
+		k := p.Kind()
+		switch k {
+		case types.LongKind:
+			code = append(code, 0x16, byte(slot)) // lload
+		case types.FloatKind:
+			code = append(code, 0x17, byte(slot)) // fload
+		case types.DoubleKind:
+			code = append(code, 0x18, byte(slot)) // dload
+		case types.ClassKind, types.ArrayKind:
+			code = append(code, 0x19, byte(slot)) // aload
+		default:
+			code = append(code, 0x15, byte(slot)) // iload
+		}
+		slot++
+		if k == types.LongKind || k == types.DoubleKind {
+			slot++
+		}
+		if w, boxed := types.BoxedName(k); boxed {
+			op(0xb8, a.MethodRef(w.AsPath(), "valueOf", "("+k.TypeString()+")L"+w.AsPath()+";")) // invokestatic
+		}
+		code = append(code, 0x53) // aastore
+	}
+	op(0xb8, a.MethodRef(goOverrideClass.AsPath(), "invoke", overrideSig.String()))
+
+	switch k := sig.Return.Kind(); k {
+	case types.VoidKind:
+		code = append(code, 0x57, 0xb1) // pop, return
+	case types.ClassKind:
+		op(0xc0, a.Class(sig.Return.(types.Class).Klass.AsPath())) // checkcast
+		code = append(code, 0xb0)                                  // areturn
+	case types.ArrayKind:
+		op(0xc0, a.Class(sig.Return.TypeString()))
+		code = append(code, 0xb0)
+	default:
+		w, _ := types.BoxedName(k)
+		op(0xc0, a.Class(w.AsPath()))
+		op(0xb6, a.MethodRef(w.AsPath(), unboxMethods[k], "()"+k.TypeString())) // invokevirtual
+		switch k {
+		case types.LongKind:
+			code = append(code, 0xad) // lreturn
+		case types.FloatKind:
+			code = append(code, 0xae) // freturn
+		case types.DoubleKind:
+			code = append(code, 0xaf) // dreturn
+		default:
+			code = append(code, 0xac) // ireturn
+		}
+	}
+	return code, uint16(slot)
+}
+
+/*
+	assembles:
+
+		public final class org.golang.ext.gojvm.GoOverride {
+			public static native Object invoke(long overrides, int index, Object self, Object[] args);
+		}
+*/
+func goOverrideBytes() []byte {
+	a := classfile.NewWriter()
+	a.NativeMethod(classfile.AccPublic|classfile.AccStatic|classfile.AccNative, "invoke", overrideSig.String())
+	return a.Bytes(classfile.AccPublic|classfile.AccFinal|classfile.AccSuper, goOverrideClass.AsPath(), types.JavaLangObject.AsPath())
+}
@@ -0,0 +1,32 @@
+package types
+
+// the java/lang wrapper class for each primitive kind
+var boxes = map[Kind]Name{
+	BoolKind:   JavaLangBoolean,
+	ByteKind:   JavaLangByte,
+	CharKind:   JavaLangCharacter,
+	ShortKind:  JavaLangShort,
+	IntKind:    JavaLangInteger,
+	LongKind:   JavaLangLong,
+	FloatKind:  JavaLangFloat,
+	DoubleKind: JavaLangDouble,
+}
+
+// Returns the name of the wrapper class used to box primitives of kind k
+// (e.g., IntKind => java.lang.Integer);  ok is false for non-primitive kinds.
+func BoxedName(k Kind) (n Name, ok bool) {
+	n, ok = boxes[k]
+	return
+}
+
+// Returns the primitive kind wrapped by the named class;  ok is false
+// if n is not one of the eight java/lang wrapper classes.
+func UnboxedKind(n Name) (k Kind, ok bool) {
+	path := n.AsPath()
+	for bk, bn := range boxes {
+		if bn.AsPath() == path {
+			return bk, true
+		}
+	}
+	return
+}
@@ -11,6 +11,16 @@ var JavaLangString	=	Name{"java","lang","String"}
 var JavaLangObject	=	Name{"java","lang","Object"}
 var JavaLangThrowable	=	Name{"java","lang","Throwable"}
 
+// primitive wrappers
+var JavaLangBoolean	=	Name{"java","lang","Boolean"}
+var JavaLangByte	=	Name{"java","lang","Byte"}
+var JavaLangCharacter	=	Name{"java","lang","Character"}
+var JavaLangShort	=	Name{"java","lang","Short"}
+var JavaLangInteger	=	Name{"java","lang","Integer"}
+var JavaLangLong	=	Name{"java","lang","Long"}
+var JavaLangFloat	=	Name{"java","lang","Float"}
+var JavaLangDouble	=	Name{"java","lang","Double"}
+
 /*
 	Parse a string by splitting on '.' and '/';  $Refs are left intact;
 	returns a new Name instance
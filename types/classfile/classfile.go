@@ -0,0 +1,326 @@
+/*
+	Reads Java class files (and jars, and directories of them) without a
+	JVM:  the constant pool, the class's access flags, super class and
+	interfaces, and its fields and methods, with their descriptors parsed into
+	types and their Signature, Exceptions and ConstantValue attributes
+	decoded.
+*/
+package classfile
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/timob/gojvm/types"
+)
+
+// The access & property flags of a class or member, as the class file format defines them
+type Access uint16
+
+const (
+	AccPublic       Access = 0x0001
+	AccPrivate      Access = 0x0002
+	AccProtected    Access = 0x0004
+	AccStatic       Access = 0x0008
+	AccFinal        Access = 0x0010
+	AccSuper        Access = 0x0020 // classes
+	AccSynchronized Access = 0x0020 // methods
+	AccVolatile     Access = 0x0040 // fields
+	AccBridge       Access = 0x0040 // methods
+	AccTransient    Access = 0x0080 // fields
+	AccVarargs      Access = 0x0080 // methods
+	AccNative       Access = 0x0100
+	AccInterface    Access = 0x0200
+	AccAbstract     Access = 0x0400
+	AccStrict       Access = 0x0800
+	AccSynthetic    Access = 0x1000
+	AccAnnotation   Access = 0x2000
+	AccEnum         Access = 0x4000
+	AccModule       Access = 0x8000
+)
+
+// true if all of the flags in a are set
+func (self Access) Has(a Access) bool { return self&a == a }
+
+// an attribute, as it appears in the class file (Info refers into the constant pool)
+type Attribute struct {
+	Name string
+	Info []byte
+}
+
+/*
+	A parsed class file.  Super is nil for java/lang/Object (and
+	module-info);  Signature is the generic signature, if the class has one.
+*/
+type Class struct {
+	Minor      uint16
+	Major      uint16
+	Pool       ConstantPool
+	Access     Access
+	Name       types.Name
+	Super      types.Name
+	Interfaces []types.Name
+	Fields     []*Field
+	Methods    []*Method
+	Signature  string
+	SourceFile string
+	Attributes []Attribute
+}
+
+/*
+	A field;  Type is its parsed Descriptor, Signature its generic signature
+	(if any), and Constant the value of its ConstantValue attribute (an
+	int32, float32, int64, float64 or string), if any.
+*/
+type Field struct {
+	Access     Access
+	Name       string
+	Descriptor string
+	Type       types.Typed
+	Signature  string
+	Constant   interface{}
+	Attributes []Attribute
+}
+
+/*
+	A method (or constructor, "<init>", or static initializer, "<clinit>");
+	Type is its parsed Descriptor, Signature its generic signature (if any),
+	and Exceptions the classes of its throws clause.
+*/
+type Method struct {
+	Access     Access
+	Name       string
+	Descriptor string
+	Type       types.MethodSignature
+	Signature  string
+	Exceptions []types.Name
+	Attributes []Attribute
+}
+
+// the method of the name and descriptor, or nil
+func (self *Class) Method(name, desc string) *Method {
+	for _, m := range self.Methods {
+		if m.Name == name && m.Descriptor == desc {
+			return m
+		}
+	}
+	return nil
+}
+
+// the field of the name, or nil
+func (self *Class) Field(name string) *Field {
+	for _, f := range self.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// the attribute of the name (or nil, false)
+func findAttribute(attrs []Attribute, name string) (info []byte, ok bool) {
+	for _, a := range attrs {
+		if a.Name == name {
+			return a.Info, true
+		}
+	}
+	return nil, false
+}
+
+const magic = 0xCAFEBABE
+
+/* Parses a class file */
+func Parse(data []byte) (class *Class, err error) {
+	r := &reader{data: data}
+	if r.u4() != magic {
+		return nil, errors.New("Not a class file (bad magic)")
+	}
+	class = &Class{}
+	class.Minor, class.Major = r.u2(), r.u2()
+	class.Pool = r.constantPool()
+	class.Access = Access(r.u2())
+	this, super := r.u2(), r.u2()
+	ifaces := make([]uint16, r.u2())
+	for i := range ifaces {
+		ifaces[i] = r.u2()
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	if class.Name, err = class.Pool.ClassName(this); err != nil {
+		return nil, err
+	}
+	if super != 0 {
+		if class.Super, err = class.Pool.ClassName(super); err != nil {
+			return nil, err
+		}
+	}
+	for _, i := range ifaces {
+		var name types.Name
+		if name, err = class.Pool.ClassName(i); err != nil {
+			return nil, err
+		}
+		class.Interfaces = append(class.Interfaces, name)
+	}
+
+	for n := r.u2(); n > 0 && r.err == nil; n-- {
+		f := &Field{Access: Access(r.u2())}
+		if f.Name, f.Descriptor, f.Attributes, err = r.member(class.Pool); err != nil {
+			return nil, err
+		}
+		if f.Type, err = types.ParseTypeString(f.Descriptor); err != nil {
+			return nil, err
+		}
+		if f.Signature, err = signature(class.Pool, f.Attributes); err != nil {
+			return nil, err
+		}
+		if info, ok := findAttribute(f.Attributes, "ConstantValue"); ok {
+			if f.Constant, err = class.Pool.Value(attrIndex(info)); err != nil {
+				return nil, err
+			}
+		}
+		class.Fields = append(class.Fields, f)
+	}
+	for n := r.u2(); n > 0 && r.err == nil; n-- {
+		m := &Method{Access: Access(r.u2())}
+		if m.Name, m.Descriptor, m.Attributes, err = r.member(class.Pool); err != nil {
+			return nil, err
+		}
+		if m.Type, err = types.ParseMethodSignature(m.Descriptor); err != nil {
+			return nil, err
+		}
+		if m.Signature, err = signature(class.Pool, m.Attributes); err != nil {
+			return nil, err
+		}
+		if info, ok := findAttribute(m.Attributes, "Exceptions"); ok {
+			if m.Exceptions, err = exceptions(class.Pool, info); err != nil {
+				return nil, err
+			}
+		}
+		class.Methods = append(class.Methods, m)
+	}
+	if class.Attributes = r.attributes(class.Pool); r.err != nil {
+		return nil, r.err
+	}
+	if class.Signature, err = signature(class.Pool, class.Attributes); err != nil {
+		return nil, err
+	}
+	if info, ok := findAttribute(class.Attributes, "SourceFile"); ok {
+		if class.SourceFile, err = class.Pool.Utf8(attrIndex(info)); err != nil {
+			return nil, err
+		}
+	}
+	if r.off != len(r.data) {
+		return nil, fmt.Errorf("%d trailing bytes after the class file", len(r.data)-r.off)
+	}
+	return
+}
+
+// the constant pool index an attribute (ConstantValue, Signature, SourceFile) consists of
+func attrIndex(info []byte) uint16 {
+	if len(info) != 2 {
+		return 0 // never a valid index
+	}
+	return binary.BigEndian.Uint16(info)
+}
+
+// the Signature attribute among attrs, or ""
+func signature(pool ConstantPool, attrs []Attribute) (s string, err error) {
+	if info, ok := findAttribute(attrs, "Signature"); ok {
+		s, err = pool.Utf8(attrIndex(info))
+	}
+	return
+}
+
+// the classes of an Exceptions attribute
+func exceptions(pool ConstantPool, info []byte) (names []types.Name, err error) {
+	r := &reader{data: info}
+	for n := r.u2(); n > 0 && r.err == nil; n-- {
+		var name types.Name
+		if name, err = pool.ClassName(r.u2()); err != nil {
+			return
+		}
+		names = append(names, name)
+	}
+	return names, r.err
+}
+
+// a big-endian reader of class file data;  the first error sticks
+type reader struct {
+	data []byte
+	off  int
+	err  error
+}
+
+func (self *reader) fail(err error) {
+	if self.err == nil {
+		self.err = err
+	}
+}
+
+// the next n bytes (or nil, at the end of the data)
+func (self *reader) bytes(n int) (b []byte) {
+	if self.err != nil {
+		return nil
+	}
+	if n < 0 || self.off+n > len(self.data) {
+		self.fail(errors.New("Truncated class file"))
+		return nil
+	}
+	b = self.data[self.off : self.off+n]
+	self.off += n
+	return
+}
+
+func (self *reader) u1() uint8 {
+	if b := self.bytes(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (self *reader) u2() uint16 {
+	if b := self.bytes(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (self *reader) u4() uint32 {
+	if b := self.bytes(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (self *reader) u8() uint64 {
+	if b := self.bytes(8); b != nil {
+		return binary.BigEndian.Uint64(b)
+	}
+	return 0
+}
+
+func (self *reader) attributes(pool ConstantPool) (attrs []Attribute) {
+	for n := self.u2(); n > 0 && self.err == nil; n-- {
+		var a Attribute
+		var err error
+		a.Name, err = pool.Utf8(self.u2())
+		self.fail(err)
+		a.Info = self.bytes(int(self.u4()))
+		attrs = append(attrs, a)
+	}
+	return
+}
+
+// the name, descriptor and attributes of a field_info or method_info (after its access flags)
+func (self *reader) member(pool ConstantPool) (name, desc string, attrs []Attribute, err error) {
+	ni, di := self.u2(), self.u2()
+	attrs = self.attributes(pool)
+	if self.err != nil {
+		return "", "", nil, self.err
+	}
+	if name, err = pool.Utf8(ni); err == nil {
+		desc, err = pool.Utf8(di)
+	}
+	return
+}
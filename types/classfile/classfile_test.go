@@ -0,0 +1,186 @@
+package classfile
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"github.com/timob/gojvm/types"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+/* Parsing hand-assembled class files, alone, in a jar and in a directory;
+
+Verifies:
+	The constant pool (including two-slot longs and modified UTF-8)
+	Names of the class, super class and interfaces
+	Fields and methods, with parsed descriptors
+	Signature, Exceptions, ConstantValue and SourceFile attributes
+	Truncated and malformed files are refused
+*/
+
+func fatalIf(t *testing.T, cond bool, msg string, args ...interface{}) {
+	if cond {
+		t.Fatalf(msg, args...)
+	}
+}
+
+func fatalInEq(t *testing.T, val interface{}, val2 interface{}, msg string, args ...interface{}) {
+	args = append(args, []interface{}{val, val2}...)
+	fatalIf(t, val != val2, msg+" (Expected: %v;\t Got: %v)", args...)
+}
+
+// big-endian class file bytes
+type out struct {
+	bytes.Buffer
+}
+
+func (self *out) u(vs ...interface{}) *out {
+	for _, v := range vs {
+		binary.Write(self, binary.BigEndian, v)
+	}
+	return self
+}
+
+func (self *out) utf8(s string) *out {
+	return self.u(uint8(1), uint16(len(s))).u([]byte(s))
+}
+
+/*
+	assembles:
+
+		public final class test.Sample extends java.util.AbstractList<String> implements java.io.Serializable {
+			public static final long BIG = 1L << 40;
+			private java.util.List<String> items;
+			public native int size();
+			public String get(int i) throws java.io.IOException;	// (no code)
+		}
+
+	with one constant named "nul\0 and 𝄞" (modified UTF-8).
+*/
+func sampleClass() []byte {
+	var pool out
+	pool.utf8("test/Sample")                                                           // 1
+	pool.u(uint8(7), uint16(1))                                                        // 2 Class test/Sample
+	pool.utf8("java/util/AbstractList")                                                // 3
+	pool.u(uint8(7), uint16(3))                                                        // 4 Class
+	pool.utf8("java/io/Serializable")                                                  // 5
+	pool.u(uint8(7), uint16(5))                                                        // 6 Class
+	pool.utf8("BIG")                                                                   // 7
+	pool.utf8("J")                                                                     // 8
+	pool.u(uint8(5), int64(1)<<40)                                                     // 9 (and 10) Long
+	pool.utf8("ConstantValue")                                                         // 11
+	pool.utf8("items")                                                                 // 12
+	pool.utf8("Ljava/util/List;")                                                      // 13
+	pool.utf8("Signature")                                                             // 14
+	pool.utf8("Ljava/util/List<Ljava/lang/String;>;")                                  // 15
+	pool.utf8("size")                                                                  // 16
+	pool.utf8("()I")                                                                   // 17
+	pool.utf8("get")                                                                   // 18
+	pool.utf8("(I)Ljava/lang/String;")                                                 // 19
+	pool.utf8("Exceptions")                                                            // 20
+	pool.utf8("java/io/IOException")                                                   // 21
+	pool.u(uint8(7), uint16(21))                                                       // 22 Class
+	pool.utf8("Ljava/util/AbstractList<Ljava/lang/String;>;Ljava/io/Serializable;")    // 23
+	pool.utf8("SourceFile")                                                            // 24
+	pool.utf8("Sample.java")                                                           // 25
+	pool.u(uint8(1), uint16(16)).u([]byte("nul\xc0\x80 and \xed\xa0\xb4\xed\xb4\x9e")) // 26
+
+	var c out
+	c.u(uint32(0xCAFEBABE), uint16(0), uint16(52), uint16(27)).u(pool.Bytes())
+	c.u(uint16(AccPublic|AccFinal|AccSuper), uint16(2), uint16(4), uint16(1), uint16(6))
+	c.u(uint16(2))
+	c.u(uint16(AccPublic|AccStatic|AccFinal), uint16(7), uint16(8), uint16(1), uint16(11), uint32(2), uint16(9))
+	c.u(uint16(AccPrivate), uint16(12), uint16(13), uint16(1), uint16(14), uint32(2), uint16(15))
+	c.u(uint16(2))
+	c.u(uint16(AccPublic|AccNative), uint16(16), uint16(17), uint16(0))
+	c.u(uint16(AccPublic|AccAbstract), uint16(18), uint16(19), uint16(1), uint16(20), uint32(4), uint16(1), uint16(22))
+	c.u(uint16(2), uint16(14), uint32(2), uint16(23), uint16(24), uint32(2), uint16(25))
+	return c.Bytes()
+}
+
+func checkSample(t *testing.T, class *Class) {
+	fatalInEq(t, uint16(52), class.Major, "Wrong major version")
+	fatalInEq(t, "test/Sample", class.Name.AsPath(), "Wrong name")
+	fatalInEq(t, "java/util/AbstractList", class.Super.AsPath(), "Wrong super")
+	fatalInEq(t, 1, len(class.Interfaces), "Wrong interface count")
+	fatalInEq(t, "java/io/Serializable", class.Interfaces[0].AsPath(), "Wrong interface")
+	fatalIf(t, !class.Access.Has(AccPublic|AccFinal), "Wrong class access %x", class.Access)
+	fatalInEq(t, "Sample.java", class.SourceFile, "Wrong source file")
+	fatalInEq(t, "Ljava/util/AbstractList<Ljava/lang/String;>;Ljava/io/Serializable;", class.Signature, "Wrong class signature")
+	fatalInEq(t, TagLong, class.Pool[9].Tag, "Wrong tag for the long")
+	fatalInEq(t, Tag(0), class.Pool[10].Tag, "The entry after a long should be empty")
+	s, err := class.Pool.Utf8(26)
+	fatalIf(t, err != nil, "Couldn't read the modified UTF-8 constant: %v", err)
+	fatalInEq(t, "nul\x00 and \U0001D11E", s, "Wrong modified UTF-8 decoding")
+
+	big := class.Field("BIG")
+	fatalIf(t, big == nil, "No field BIG")
+	fatalInEq(t, types.LongKind, big.Type.Kind(), "Wrong BIG type")
+	fatalInEq(t, int64(1)<<40, big.Constant, "Wrong BIG constant")
+	items := class.Field("items")
+	fatalIf(t, items == nil, "No field items")
+	fatalInEq(t, "Ljava/util/List;", items.Type.TypeString(), "Wrong items type")
+	fatalInEq(t, "Ljava/util/List<Ljava/lang/String;>;", items.Signature, "Wrong items signature")
+	fatalInEq(t, nil, items.Constant, "items shouldn't have a constant")
+
+	size := class.Method("size", "()I")
+	fatalIf(t, size == nil, "No method size")
+	fatalIf(t, !size.Access.Has(AccNative), "size should be native")
+	fatalInEq(t, types.IntKind, size.Type.Return.Kind(), "Wrong size return")
+	get := class.Method("get", "(I)Ljava/lang/String;")
+	fatalIf(t, get == nil, "No method get")
+	fatalInEq(t, 1, len(get.Type.Params), "Wrong get parameter count")
+	fatalInEq(t, 1, len(get.Exceptions), "Wrong get exception count")
+	fatalInEq(t, "java/io/IOException", get.Exceptions[0].AsPath(), "Wrong get exception")
+}
+
+func TestParse(t *testing.T) {
+	class, err := Parse(sampleClass())
+	fatalIf(t, err != nil, "Couldn't parse: %v", err)
+	checkSample(t, class)
+
+	data := sampleClass()
+	for _, n := range []int{0, 3, 9, 40, len(data) - 1} {
+		_, err = Parse(data[:n])
+		fatalIf(t, err == nil, "Parsing %d of %d bytes should fail", n, len(data))
+	}
+	_, err = Parse(append(data, 0))
+	fatalIf(t, err == nil, "Parsing with a trailing byte should fail")
+	bad := sampleClass()
+	bad[8+2] = 2 // the tag of the first constant
+	_, err = Parse(bad)
+	fatalIf(t, err == nil, "Parsing an unknown constant tag should fail")
+}
+
+func TestReadJarAndDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "classfile")
+	fatalIf(t, err != nil, "Couldn't make a temporary directory: %v", err)
+	defer os.RemoveAll(dir)
+
+	jarPath := filepath.Join(dir, "sample.jar")
+	f, err := os.Create(jarPath)
+	fatalIf(t, err != nil, "Couldn't create the jar: %v", err)
+	zw := zip.NewWriter(f)
+	for _, name := range []string{"META-INF/MANIFEST.MF", "test/Sample.class", "test/readme.txt", "META-INF/versions/9/test/Sample.class"} {
+		w, err := zw.Create(name)
+		fatalIf(t, err != nil, "Couldn't add %s: %v", name, err)
+		w.Write(sampleClass())
+	}
+	fatalIf(t, zw.Close() != nil || f.Close() != nil, "Couldn't write the jar")
+
+	classes, err := Read(jarPath)
+	fatalIf(t, err != nil, "Couldn't read the jar: %v", err)
+	fatalInEq(t, 1, len(classes), "Wrong jar class count")
+	checkSample(t, classes[0])
+
+	classDir := filepath.Join(dir, "classes", "test")
+	fatalIf(t, os.MkdirAll(classDir, 0755) != nil, "Couldn't make the class directory")
+	fatalIf(t, ioutil.WriteFile(filepath.Join(classDir, "Sample.class"), sampleClass(), 0644) != nil, "Couldn't write the class")
+	classes, err = Read(filepath.Join(dir, "classes"))
+	fatalIf(t, err != nil, "Couldn't read the directory: %v", err)
+	fatalInEq(t, 1, len(classes), "Wrong directory class count")
+	checkSample(t, classes[0])
+}
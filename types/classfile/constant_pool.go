@@ -0,0 +1,214 @@
+package classfile
+
+import (
+	"errors"
+	"fmt"
+	"github.com/timob/gojvm/types"
+	"math"
+	"unicode/utf16"
+)
+
+// constant pool tags
+type Tag uint8
+
+const (
+	TagUtf8               Tag = 1
+	TagInteger            Tag = 3
+	TagFloat              Tag = 4
+	TagLong               Tag = 5
+	TagDouble             Tag = 6
+	TagClass              Tag = 7
+	TagString             Tag = 8
+	TagFieldref           Tag = 9
+	TagMethodref          Tag = 10
+	TagInterfaceMethodref Tag = 11
+	TagNameAndType        Tag = 12
+	TagMethodHandle       Tag = 15
+	TagMethodType         Tag = 16
+	TagDynamic            Tag = 17
+	TagInvokeDynamic      Tag = 18
+	TagModule             Tag = 19
+	TagPackage            Tag = 20
+)
+
+/*
+	An entry of the constant pool.  Value holds the string of a Utf8, and
+	the int32, float32, int64 or float64 of a number;  Ref1 and Ref2 hold the
+	indices (or, for a MethodHandle, the kind and index) others refer to:
+
+		Class, String, MethodType, Module, Package:	Ref1 (the Utf8 name)
+		Fieldref, Methodref, InterfaceMethodref:	Ref1 (Class), Ref2 (NameAndType)
+		NameAndType:	Ref1 (name), Ref2 (descriptor)
+		MethodHandle:	Ref1 (reference kind), Ref2 (the ref)
+		Dynamic, InvokeDynamic:	Ref1 (bootstrap method), Ref2 (NameAndType)
+
+	Index 0, and the index following a Long or Double, hold a Tag 0 entry.
+*/
+type Constant struct {
+	Tag   Tag
+	Value interface{}
+	Ref1  uint16
+	Ref2  uint16
+}
+
+type ConstantPool []Constant
+
+// the entry at index i, or an error if there is none of the tag
+func (self ConstantPool) entry(i uint16, tag Tag) (c Constant, err error) {
+	if int(i) >= len(self) || self[i].Tag != tag {
+		return c, fmt.Errorf("Constant pool index %d is not a %s", i, tag)
+	}
+	return self[i], nil
+}
+
+// the string of the Utf8 at index i
+func (self ConstantPool) Utf8(i uint16) (s string, err error) {
+	c, err := self.entry(i, TagUtf8)
+	if err == nil {
+		s = c.Value.(string)
+	}
+	return
+}
+
+// the name of the Class at index i
+func (self ConstantPool) ClassName(i uint16) (name types.Name, err error) {
+	c, err := self.entry(i, TagClass)
+	if err != nil {
+		return
+	}
+	s, err := self.Utf8(c.Ref1)
+	if err == nil {
+		name = types.NewName(s)
+	}
+	return
+}
+
+// the name and descriptor of the NameAndType at index i
+func (self ConstantPool) NameAndType(i uint16) (name, desc string, err error) {
+	c, err := self.entry(i, TagNameAndType)
+	if err != nil {
+		return
+	}
+	if name, err = self.Utf8(c.Ref1); err == nil {
+		desc, err = self.Utf8(c.Ref2)
+	}
+	return
+}
+
+/*
+	The class, name and descriptor of the Fieldref, Methodref or
+	InterfaceMethodref at index i.
+*/
+func (self ConstantPool) MemberRef(i uint16) (class types.Name, name, desc string, err error) {
+	if int(i) >= len(self) {
+		return nil, "", "", fmt.Errorf("Constant pool index %d out of range", i)
+	}
+	switch c := self[i]; c.Tag {
+	case TagFieldref, TagMethodref, TagInterfaceMethodref:
+		if class, err = self.ClassName(c.Ref1); err == nil {
+			name, desc, err = self.NameAndType(c.Ref2)
+		}
+	default:
+		err = fmt.Errorf("Constant pool index %d is not a member ref", i)
+	}
+	return
+}
+
+/*
+	The value of the loadable constant at index i, as a ConstantValue
+	attribute holds:  an int32, float32, int64, float64 or (for a String)
+	string.
+*/
+func (self ConstantPool) Value(i uint16) (v interface{}, err error) {
+	if int(i) >= len(self) {
+		return nil, fmt.Errorf("Constant pool index %d out of range", i)
+	}
+	switch c := self[i]; c.Tag {
+	case TagInteger, TagFloat, TagLong, TagDouble:
+		return c.Value, nil
+	case TagString:
+		return self.Utf8(c.Ref1)
+	}
+	return nil, fmt.Errorf("Constant pool index %d is not a constant value", i)
+}
+
+var tagNames = map[Tag]string{
+	TagUtf8: "Utf8", TagInteger: "Integer", TagFloat: "Float", TagLong: "Long",
+	TagDouble: "Double", TagClass: "Class", TagString: "String",
+	TagFieldref: "Fieldref", TagMethodref: "Methodref",
+	TagInterfaceMethodref: "InterfaceMethodref", TagNameAndType: "NameAndType",
+	TagMethodHandle: "MethodHandle", TagMethodType: "MethodType",
+	TagDynamic: "Dynamic", TagInvokeDynamic: "InvokeDynamic",
+	TagModule: "Module", TagPackage: "Package",
+}
+
+func (self Tag) String() string {
+	if s, ok := tagNames[self]; ok {
+		return s
+	}
+	return fmt.Sprintf("Tag(%d)", uint8(self))
+}
+
+// parses the constant pool (of count-1 entries, from index 1)
+func (self *reader) constantPool() (pool ConstantPool) {
+	count := self.u2()
+	if count == 0 {
+		self.fail(errors.New("Empty constant pool"))
+		return
+	}
+	pool = make(ConstantPool, count)
+	for i := 1; i < int(count) && self.err == nil; i++ {
+		c := Constant{Tag: Tag(self.u1())}
+		switch c.Tag {
+		case TagUtf8:
+			var err error
+			c.Value, err = decodeUtf8(self.bytes(int(self.u2())))
+			self.fail(err)
+		case TagInteger:
+			c.Value = int32(self.u4())
+		case TagFloat:
+			c.Value = math.Float32frombits(self.u4())
+		case TagLong:
+			c.Value = int64(self.u8())
+		case TagDouble:
+			c.Value = math.Float64frombits(self.u8())
+		case TagClass, TagString, TagMethodType, TagModule, TagPackage:
+			c.Ref1 = self.u2()
+		case TagFieldref, TagMethodref, TagInterfaceMethodref, TagNameAndType, TagDynamic, TagInvokeDynamic:
+			c.Ref1, c.Ref2 = self.u2(), self.u2()
+		case TagMethodHandle:
+			c.Ref1, c.Ref2 = uint16(self.u1()), self.u2()
+		default:
+			self.fail(fmt.Errorf("Unknown constant pool tag %d at index %d", c.Tag, i))
+		}
+		pool[i] = c
+		if c.Tag == TagLong || c.Tag == TagDouble {
+			i++ // takes two entries
+		}
+	}
+	return
+}
+
+/*
+	Decodes the "modified UTF-8" of class files:  NUL is encoded as two
+	bytes, and supplementary characters as (3-byte encoded) surrogate pairs.
+*/
+func decodeUtf8(b []byte) (s string, err error) {
+	units := make([]uint16, 0, len(b))
+	for i := 0; i < len(b); {
+		switch c := b[i]; {
+		case c&0x80 == 0 && c != 0:
+			units = append(units, uint16(c))
+			i++
+		case c&0xe0 == 0xc0 && i+1 < len(b) && b[i+1]&0xc0 == 0x80:
+			units = append(units, uint16(c&0x1f)<<6|uint16(b[i+1]&0x3f))
+			i += 2
+		case c&0xf0 == 0xe0 && i+2 < len(b) && b[i+1]&0xc0 == 0x80 && b[i+2]&0xc0 == 0x80:
+			units = append(units, uint16(c&0x0f)<<12|uint16(b[i+1]&0x3f)<<6|uint16(b[i+2]&0x3f))
+			i += 3
+		default:
+			return "", fmt.Errorf("Malformed modified UTF-8 at byte %d", i)
+		}
+	}
+	return string(utf16.Decode(units)), nil
+}
@@ -0,0 +1,94 @@
+package classfile
+
+import (
+	"archive/zip"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/* Parses the class file at path */
+func ReadFile(path string) (class *Class, err error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+	if class, err = Parse(data); err != nil {
+		err = fmt.Errorf("%s: %v", path, err)
+	}
+	return
+}
+
+/*
+	Parses the classes of the jar (or zip) at path, in the order it lists
+	them;  the META-INF tree (including multi-release versions) is skipped.
+*/
+func ReadJar(path string) (classes []*Class, err error) {
+	jar, err := zip.OpenReader(path)
+	if err != nil {
+		return
+	}
+	defer jar.Close()
+	for _, f := range jar.File {
+		if !strings.HasSuffix(f.Name, ".class") || strings.HasPrefix(f.Name, "META-INF/") {
+			continue
+		}
+		var class *Class
+		if class, err = readJarEntry(f); err != nil {
+			return nil, fmt.Errorf("%s!%s: %v", path, f.Name, err)
+		}
+		classes = append(classes, class)
+	}
+	return
+}
+
+func readJarEntry(f *zip.File) (class *Class, err error) {
+	rc, err := f.Open()
+	if err != nil {
+		return
+	}
+	defer rc.Close()
+	data, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return
+	}
+	return Parse(data)
+}
+
+// Parses the .class files under the directory root (a class path entry), in lexical order
+func ReadDir(root string) (classes []*Class, err error) {
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !strings.HasSuffix(path, ".class") {
+			return err
+		}
+		class, err := ReadFile(path)
+		if err == nil {
+			classes = append(classes, class)
+		}
+		return err
+	})
+	return
+}
+
+/*
+	Parses the classes of a class path entry:  a jar (or zip), a directory,
+	or a single class file.
+*/
+func Read(path string) (classes []*Class, err error) {
+	info, err := os.Stat(path)
+	switch {
+	case err != nil:
+		return
+	case info.IsDir():
+		return ReadDir(path)
+	case strings.HasSuffix(path, ".class"):
+		var class *Class
+		if class, err = ReadFile(path); err == nil {
+			classes = []*Class{class}
+		}
+		return
+	}
+	return ReadJar(path)
+}
@@ -0,0 +1,311 @@
+package classfile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"github.com/timob/gojvm/types"
+	"math"
+	"unicode/utf16"
+)
+
+type buffer struct {
+	bytes.Buffer
+}
+
+func (self *buffer) u1(v uint8)  { self.WriteByte(v) }
+func (self *buffer) u2(v uint16) { binary.Write(self, binary.BigEndian, v) }
+func (self *buffer) u4(v uint32) { binary.Write(self, binary.BigEndian, v) }
+func (self *buffer) u8(v uint64) { binary.Write(self, binary.BigEndian, v) }
+
+/*
+	Assembles a class file, for glue classes to define at run time (see
+	gojvm's DefineClass):  add fields and methods, then take the Bytes.
+	Names are internal ("java/lang/Thread"), types descriptors ("J").
+
+		w := classfile.NewWriter()
+		w.Field(classfile.AccPublic, "id", "J")
+		w.DefaultConstructor("java/lang/Object")
+		w.NativeMethod(classfile.AccPublic|classfile.AccNative, "run", "()V")
+		data := w.Bytes(classfile.AccPublic|classfile.AccSuper, "org/example/Task",
+			"java/lang/Object", "java/lang/Runnable")
+
+	Constants are added to the pool as members (or code, via the constant
+	methods) use them.  Major defaults to 50 (Java 6), the latest version
+	whose code needs no stack map frames, which the Writer doesn't compute.
+*/
+type Writer struct {
+	Major    uint16
+	pool     buffer
+	count    uint16
+	consts   map[string]uint16
+	fields   buffer
+	nfields  uint16
+	methods  buffer
+	nmethods uint16
+	attrs    []Attribute
+}
+
+func NewWriter() *Writer {
+	return &Writer{Major: 50, count: 1, consts: map[string]uint16{}}
+}
+
+// the index of a constant, adding it (as written by emit, taking size entries) if it is new
+func (self *Writer) constant(key string, size uint16, emit func()) uint16 {
+	if i, ok := self.consts[key]; ok {
+		return i
+	}
+	emit()
+	i := self.count
+	self.consts[key] = i
+	self.count += size
+	return i
+}
+
+// the index of a Utf8 constant
+func (self *Writer) Utf8(s string) uint16 {
+	return self.constant("U"+s, 1, func() {
+		b := encodeUtf8(s)
+		self.pool.u1(uint8(TagUtf8))
+		self.pool.u2(uint16(len(b)))
+		self.pool.Write(b)
+	})
+}
+
+// the index of a Class constant
+func (self *Writer) Class(name string) uint16 {
+	n := self.Utf8(name)
+	return self.constant("C"+name, 1, func() {
+		self.pool.u1(uint8(TagClass))
+		self.pool.u2(n)
+	})
+}
+
+// the index of a String constant
+func (self *Writer) String(s string) uint16 {
+	n := self.Utf8(s)
+	return self.constant("S"+s, 1, func() {
+		self.pool.u1(uint8(TagString))
+		self.pool.u2(n)
+	})
+}
+
+/*
+	The index of a numeric constant:  v is an int32 (Integer), float32
+	(Float), int64 (Long) or float64 (Double).
+*/
+func (self *Writer) Number(v interface{}) uint16 {
+	key := fmt.Sprintf("%T:%v", v, v)
+	switch n := v.(type) {
+	case int32:
+		return self.constant(key, 1, func() {
+			self.pool.u1(uint8(TagInteger))
+			self.pool.u4(uint32(n))
+		})
+	case float32:
+		key = fmt.Sprintf("F%x", math.Float32bits(n))
+		return self.constant(key, 1, func() {
+			self.pool.u1(uint8(TagFloat))
+			self.pool.u4(math.Float32bits(n))
+		})
+	case int64:
+		return self.constant(key, 2, func() {
+			self.pool.u1(uint8(TagLong))
+			self.pool.u8(uint64(n))
+		})
+	case float64:
+		key = fmt.Sprintf("D%x", math.Float64bits(n))
+		return self.constant(key, 2, func() {
+			self.pool.u1(uint8(TagDouble))
+			self.pool.u8(math.Float64bits(n))
+		})
+	}
+	panic(fmt.Sprintf("classfile: %T is not a numeric constant", v))
+}
+
+func (self *Writer) NameAndType(name, desc string) uint16 {
+	n, d := self.Utf8(name), self.Utf8(desc)
+	return self.constant("N"+name+":"+desc, 1, func() {
+		self.pool.u1(uint8(TagNameAndType))
+		self.pool.u2(n)
+		self.pool.u2(d)
+	})
+}
+
+func (self *Writer) memberRef(tag Tag, class, name, desc string) uint16 {
+	c, nt := self.Class(class), self.NameAndType(name, desc)
+	return self.constant(fmt.Sprintf("%d:%s.%s:%s", tag, class, name, desc), 1, func() {
+		self.pool.u1(uint8(tag))
+		self.pool.u2(c)
+		self.pool.u2(nt)
+	})
+}
+
+// the index of a Fieldref constant
+func (self *Writer) FieldRef(class, name, desc string) uint16 {
+	return self.memberRef(TagFieldref, class, name, desc)
+}
+
+// the index of a Methodref constant
+func (self *Writer) MethodRef(class, name, desc string) uint16 {
+	return self.memberRef(TagMethodref, class, name, desc)
+}
+
+// the index of an InterfaceMethodref constant
+func (self *Writer) InterfaceMethodRef(class, name, desc string) uint16 {
+	return self.memberRef(TagInterfaceMethodref, class, name, desc)
+}
+
+func (self *Writer) attributes(out *buffer, attrs []Attribute) {
+	out.u2(uint16(len(attrs)))
+	for _, a := range attrs {
+		out.u2(self.Utf8(a.Name))
+		out.u4(uint32(len(a.Info)))
+		out.Write(a.Info)
+	}
+}
+
+/*
+	Adds a field;  attrs (whose Info may refer to constants of the Writer)
+	are written as given.
+*/
+func (self *Writer) Field(access Access, name, desc string, attrs ...Attribute) {
+	self.fields.u2(uint16(access))
+	self.fields.u2(self.Utf8(name))
+	self.fields.u2(self.Utf8(desc))
+	self.attributes(&self.fields, attrs)
+	self.nfields++
+}
+
+// Adds a static final field with a ConstantValue (see Number and String)
+func (self *Writer) ConstantField(access Access, name, desc string, value uint16) {
+	self.Field(access|AccStatic|AccFinal, name, desc, Attribute{"ConstantValue", index(value)})
+}
+
+// Adds a method without code (native or abstract)
+func (self *Writer) NativeMethod(access Access, name, desc string, attrs ...Attribute) {
+	self.methods.u2(uint16(access))
+	self.methods.u2(self.Utf8(name))
+	self.methods.u2(self.Utf8(desc))
+	self.attributes(&self.methods, attrs)
+	self.nmethods++
+}
+
+// Adds a method with the given code (and no exception table)
+func (self *Writer) Method(access Access, name, desc string, maxStack, maxLocals uint16, code []byte, attrs ...Attribute) {
+	var info buffer
+	info.u2(maxStack)
+	info.u2(maxLocals)
+	info.u4(uint32(len(code)))
+	info.Write(code)
+	info.u2(0) // exception table
+	info.u2(0) // attributes
+	self.NativeMethod(access, name, desc, append([]Attribute{{"Code", info.Bytes()}}, attrs...)...)
+}
+
+// Adds a public no-arg constructor calling super's
+func (self *Writer) DefaultConstructor(super string) {
+	self.SuperConstructor(super, []types.Typed{})
+}
+
+// Adds a public constructor passing its parameters (of types params) on to super's
+func (self *Writer) SuperConstructor(super string, params []types.Typed) {
+	desc := types.MethodSignature{Params: params, Return: types.Basic(types.VoidKind)}.String()
+	m := self.MethodRef(super, "<init>", desc)
+	code := []byte{0x2a} // aload_0
+	slot := 1
+	for _, p := range params {
+		switch p.Kind() {
+		case types.LongKind:
+			code = append(code, 0x16, byte(slot)) // lload
+			slot++
+		case types.FloatKind:
+			code = append(code, 0x17, byte(slot)) // fload
+		case types.DoubleKind:
+			code = append(code, 0x18, byte(slot)) // dload
+			slot++
+		case types.ClassKind, types.ArrayKind:
+			code = append(code, 0x19, byte(slot)) // aload
+		default:
+			code = append(code, 0x15, byte(slot)) // iload
+		}
+		slot++
+	}
+	code = append(code,
+		0xb7, byte(m>>8), byte(m), // invokespecial super.<init>
+		0xb1, // return
+	)
+	self.Method(AccPublic, "<init>", desc, uint16(slot), uint16(slot), code)
+}
+
+// a Signature attribute, of the generic signature s
+func (self *Writer) Signature(s string) Attribute {
+	return Attribute{"Signature", index(self.Utf8(s))}
+}
+
+// an Exceptions attribute, of the classes a method throws
+func (self *Writer) Exceptions(classes ...string) Attribute {
+	var info buffer
+	info.u2(uint16(len(classes)))
+	for _, c := range classes {
+		info.u2(self.Class(c))
+	}
+	return Attribute{"Exceptions", info.Bytes()}
+}
+
+// Adds an attribute of the class itself
+func (self *Writer) Attribute(a Attribute) {
+	self.attrs = append(self.attrs, a)
+}
+
+// the class file of class name
+func (self *Writer) Bytes(access Access, name, super string, interfaces ...string) []byte {
+	this, sup := self.Class(name), self.Class(super)
+	ifaces := []uint16{}
+	for _, iface := range interfaces {
+		ifaces = append(ifaces, self.Class(iface))
+	}
+	var attrs buffer
+	self.attributes(&attrs, self.attrs) // (adding their names first)
+
+	var out buffer
+	out.u4(magic)
+	out.u2(0) // minor
+	out.u2(self.Major)
+	out.u2(self.count)
+	out.Write(self.pool.Bytes())
+	out.u2(uint16(access))
+	out.u2(this)
+	out.u2(sup)
+	out.u2(uint16(len(ifaces)))
+	for _, i := range ifaces {
+		out.u2(i)
+	}
+	out.u2(self.nfields)
+	out.Write(self.fields.Bytes())
+	out.u2(self.nmethods)
+	out.Write(self.methods.Bytes())
+	out.Write(attrs.Bytes())
+	return out.Bytes()
+}
+
+// the Info of an attribute consisting of a constant pool index
+func index(i uint16) []byte {
+	return []byte{byte(i >> 8), byte(i)}
+}
+
+// the modified UTF-8 of s (see decodeUtf8)
+func encodeUtf8(s string) []byte {
+	b := make([]byte, 0, len(s))
+	for _, u := range utf16.Encode([]rune(s)) {
+		switch {
+		case u != 0 && u < 0x80:
+			b = append(b, byte(u))
+		case u < 0x800:
+			b = append(b, byte(0xc0|u>>6), byte(0x80|u&0x3f))
+		default:
+			b = append(b, byte(0xe0|u>>12), byte(0x80|(u>>6)&0x3f), byte(0x80|u&0x3f))
+		}
+	}
+	return b
+}
@@ -0,0 +1,79 @@
+package classfile
+
+import (
+	"encoding/binary"
+	"github.com/timob/gojvm/types"
+	"testing"
+)
+
+/* Class files from the Writer, read back by Parse;
+
+Verifies:
+	Shared constants are pooled once, longs and doubles take two entries
+	Fields (with ConstantValue and Signature), natives and interfaces
+	Constructors calling super, loading each parameter kind
+	Exceptions and class attributes
+*/
+
+func TestWriter(t *testing.T) {
+	w := NewWriter()
+	w.ConstantField(AccPublic, "BIG", "J", w.Number(int64(1)<<40))
+	w.ConstantField(AccPublic, "HALF", "D", w.Number(0.5))
+	w.ConstantField(AccPublic, "NAME", "Ljava/lang/String;", w.String("nul\x00 and \U0001D11E"))
+	w.Field(AccPrivate, "items", "Ljava/util/List;", w.Signature("Ljava/util/List<Ljava/lang/String;>;"))
+	w.DefaultConstructor("java/util/AbstractList")
+	w.SuperConstructor("java/util/AbstractList", []types.Typed{
+		types.Basic(types.LongKind), types.Basic(types.FloatKind), types.Class{types.JavaLangString},
+		types.Basic(types.DoubleKind), types.Array{types.Basic(types.IntKind)}, types.Basic(types.BoolKind),
+	})
+	w.NativeMethod(AccPublic|AccNative, "size", "()I")
+	w.NativeMethod(AccPublic|AccNative, "get", "(I)Ljava/lang/Object;", w.Exceptions("java/io/IOException"))
+	w.Attribute(Attribute{"SourceFile", index(w.Utf8("Sample.java"))})
+	data := w.Bytes(AccPublic|AccFinal|AccSuper, "test/Sample", "java/util/AbstractList", "java/io/Serializable", "java/util/RandomAccess")
+
+	class, err := Parse(data)
+	fatalIf(t, err != nil, "Couldn't parse the written class: %v", err)
+	fatalInEq(t, uint16(50), class.Major, "Wrong major version")
+	fatalInEq(t, "test/Sample", class.Name.AsPath(), "Wrong name")
+	fatalInEq(t, "java/util/AbstractList", class.Super.AsPath(), "Wrong super")
+	fatalInEq(t, 2, len(class.Interfaces), "Wrong interface count")
+	fatalInEq(t, "java/util/RandomAccess", class.Interfaces[1].AsPath(), "Wrong interface")
+	fatalInEq(t, "Sample.java", class.SourceFile, "Wrong source file")
+	classes := 0
+	for _, c := range class.Pool {
+		if c.Tag == TagClass {
+			classes++
+		}
+	}
+	fatalInEq(t, 5, classes, "Wrong number of Class constants (each pooled once)")
+
+	fatalInEq(t, int64(1)<<40, class.Field("BIG").Constant, "Wrong BIG")
+	fatalInEq(t, 0.5, class.Field("HALF").Constant, "Wrong HALF")
+	fatalInEq(t, "nul\x00 and \U0001D11E", class.Field("NAME").Constant, "Wrong NAME")
+	fatalIf(t, !class.Field("NAME").Access.Has(AccStatic|AccFinal), "NAME should be static final")
+	fatalInEq(t, "Ljava/util/List<Ljava/lang/String;>;", class.Field("items").Signature, "Wrong items signature")
+
+	get := class.Method("get", "(I)Ljava/lang/Object;")
+	fatalIf(t, get == nil || !get.Access.Has(AccNative), "No native get")
+	fatalInEq(t, 1, len(get.Exceptions), "Wrong get exception count")
+	fatalInEq(t, "java/io/IOException", get.Exceptions[0].AsPath(), "Wrong get exception")
+
+	init := class.Method("<init>", "()V")
+	fatalIf(t, init == nil, "No default constructor")
+	ctor := class.Method("<init>", "(JFLjava/lang/String;D[IZ)V")
+	fatalIf(t, ctor == nil, "No constructor with parameters")
+	code, ok := findAttribute(ctor.Attributes, "Code")
+	fatalIf(t, !ok, "The constructor has no code")
+	fatalInEq(t, uint16(9), binary.BigEndian.Uint16(code[0:]), "Wrong max stack")
+	fatalInEq(t, uint16(9), binary.BigEndian.Uint16(code[2:]), "Wrong max locals")
+	n := binary.BigEndian.Uint32(code[4:])
+	ops := code[8 : 8+n]
+	expected := []byte{0x2a, 0x16, 1, 0x17, 3, 0x19, 4, 0x18, 5, 0x19, 7, 0x15, 8, 0xb7}
+	fatalInEq(t, len(expected)+3, len(ops), "Wrong constructor code length")
+	for i, op := range expected {
+		fatalInEq(t, op, ops[i], "Wrong constructor code at %d", i)
+	}
+	super, name, desc, err := class.Pool.MemberRef(binary.BigEndian.Uint16(ops[len(expected):]))
+	fatalIf(t, err != nil, "The constructor doesn't invoke a member: %v", err)
+	fatalInEq(t, "java/util/AbstractList.<init>(JFLjava/lang/String;D[IZ)V", super.AsPath()+"."+name+desc, "Wrong super constructor")
+}
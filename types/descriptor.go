@@ -0,0 +1,74 @@
+package types
+
+import (
+	"errors"
+)
+
+/*
+	Parses a single JNI field descriptor (e.g., "I", "[J" or "Ljava/lang/String;")
+	into a Typed.
+*/
+func ParseTypeString(s string) (t Typed, err error) {
+	t, n, err := parseType(s)
+	if err == nil && n != len(s) {
+		err = errors.New("Trailing characters in type descriptor " + s)
+	}
+	return
+}
+
+/*
+	Parses a JNI method descriptor (e.g., "(ILjava/lang/String;)V") into
+	a MethodSignature.
+*/
+func ParseMethodSignature(s string) (sig MethodSignature, err error) {
+	if len(s) == 0 || s[0] != '(' {
+		err = errors.New("Method descriptor must begin with '(': " + s)
+		return
+	}
+	i := 1
+	sig.Params = make([]Typed, 0)
+	for i < len(s) && s[i] != ')' {
+		var t Typed
+		var n int
+		t, n, err = parseType(s[i:])
+		if err != nil {
+			return
+		}
+		sig.Params = append(sig.Params, t)
+		i += n
+	}
+	if i >= len(s) {
+		err = errors.New("Unterminated method descriptor " + s)
+		return
+	}
+	sig.Return, err = ParseTypeString(s[i+1:])
+	return
+}
+
+// parses the leading type in s, returning it and the number of bytes consumed
+func parseType(s string) (t Typed, n int, err error) {
+	if len(s) == 0 {
+		err = errors.New("Empty type descriptor")
+		return
+	}
+	switch k := Kind(s[0]); k {
+	case BoolKind, ByteKind, CharKind, ShortKind, IntKind, LongKind, FloatKind, DoubleKind, VoidKind:
+		return Basic(k), 1, nil
+	case ClassKind:
+		for n = 1; n < len(s); n++ {
+			if s[n] == ';' {
+				return Class{NewName(s[1:n])}, n + 1, nil
+			}
+		}
+		err = errors.New("Unterminated class descriptor " + s)
+	case ArrayKind:
+		var u Typed
+		u, n, err = parseType(s[1:])
+		if err == nil {
+			t, n = Array{u}, n+1
+		}
+	default:
+		err = errors.New("Unknown type descriptor " + s)
+	}
+	return
+}